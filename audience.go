@@ -0,0 +1,186 @@
+package go_sdk
+
+import (
+	"context"
+	"errors"
+)
+
+var ErrNoRecipients = errors.New("message has no recipients")
+
+// Segment 返回一组成员的 open ID，在开发者代码中定义，比如从数据库中查询某个标签的用户
+type Segment func(ctx context.Context) ([]string, error)
+
+// StaticSegment 返回由固定成员组成的 Segment
+func StaticSegment(openIDs ...string) Segment {
+	return func(ctx context.Context) ([]string, error) {
+		return openIDs, nil
+	}
+}
+
+// Audience 描述消息的接收人，接收人为 Recipients、Segments 以及 All（频道全体成员）的并集，
+// 再去掉 Exclude 和 ExcludeSegments 中的成员
+type Audience struct {
+	Recipients []string
+	Segments   []Segment
+	// 频道全体成员，没有排除的成员并且不需要个性化数据时直接以 ToAll 推送，否则通过 IterateMembers 展开
+	All bool
+
+	Exclude         []string
+	ExcludeSegments []Segment
+}
+
+// PersonalizeFunc 为每个接收人生成消息数据
+type PersonalizeFunc func(openID string) (map[string]interface{}, error)
+
+// AudienceMessage 为推送给一组接收人的消息
+type AudienceMessage struct {
+	Audience Audience
+	MessageContentRequest
+	// 不为 nil 时为每个接收人单独生成消息数据，每人推送一条消息，此时 MessageContentRequest.Data 被忽略
+	Personalize PersonalizeFunc
+}
+
+// AudienceDelivery 为一次推送的结果，Recipients 为空表示发给频道全体成员
+type AudienceDelivery struct {
+	Recipients []string
+	MessageID  int64
+	Err        error
+}
+
+// AudienceResult 为 SendToAudience 的结果
+type AudienceResult struct {
+	Deliveries []AudienceDelivery
+}
+
+// Failed 返回推送失败的接收人，可以用于稍后重试
+func (r *AudienceResult) Failed() []string {
+	var failed []string
+	for _, d := range r.Deliveries {
+		if d.Err != nil {
+			failed = append(failed, d.Recipients...)
+		}
+	}
+	return failed
+}
+
+// WithMaxRecipients 设置 SendToAudience 每条消息最多包含的接收人数量，默认为 1000
+func WithMaxRecipients(n int) ClientOption {
+	return func(c *Client) {
+		if n > 0 {
+			c.maxRecipients = n
+		}
+	}
+}
+
+// SendToAudience 解析接收人并推送消息：不需要个性化数据时按 WithMaxRecipients 将接收人分为多条消息，
+// 否则每个接收人一条消息，所有消息通过 CreateMessages 批量发送。
+// 返回的 error 只表示解析接收人或生成个性化数据失败，此时不会推送任何消息；各条消息的结果见 AudienceResult
+func (c *Client) SendToAudience(ctx context.Context, am *AudienceMessage) (result *AudienceResult, err error) {
+	ctx, span := c.trace().Start(ctx, "sm.SendToAudience", SpanKindInternal, contentAttributes(&am.MessageContentRequest))
+	defer func() { span.End(err) }()
+
+	if err = am.MessageContentRequest.check(); err != nil {
+		return nil, err
+	}
+
+	audience := am.Audience
+	if audience.All && len(audience.Exclude) == 0 && len(audience.ExcludeSegments) == 0 && am.Personalize == nil {
+		span.SetAttributes(map[string]interface{}{AttrToAll: true})
+		id, err := c.CreateMessageContext(ctx, &CreateMessageRequest{ToAll: true, MessageContentRequest: am.MessageContentRequest})
+		return &AudienceResult{Deliveries: []AudienceDelivery{{MessageID: id, Err: err}}}, nil
+	}
+
+	recipients, err := c.resolveAudience(ctx, &audience)
+	if err != nil {
+		return nil, err
+	}
+	if len(recipients) == 0 {
+		return nil, ErrNoRecipients
+	}
+	span.SetAttributes(map[string]interface{}{AttrRecipientCount: len(recipients)})
+
+	var cmrs []*CreateMessageRequest
+	var groups [][]string
+	if am.Personalize != nil {
+		for _, openID := range recipients {
+			data, err := am.Personalize(openID)
+			if err != nil {
+				return nil, err
+			}
+
+			mcr := am.MessageContentRequest
+			mcr.Data = data
+			cmrs = append(cmrs, &CreateMessageRequest{Recipients: []string{openID}, MessageContentRequest: mcr})
+			groups = append(groups, []string{openID})
+		}
+	} else {
+		maxRecipients := c.maxRecipients
+		if maxRecipients < 1 {
+			maxRecipients = 1000
+		}
+
+		for start := 0; start < len(recipients); start += maxRecipients {
+			end := start + maxRecipients
+			if end > len(recipients) {
+				end = len(recipients)
+			}
+
+			group := recipients[start:end]
+			cmrs = append(cmrs, &CreateMessageRequest{Recipients: group, MessageContentRequest: am.MessageContentRequest})
+			groups = append(groups, group)
+		}
+	}
+
+	result = &AudienceResult{Deliveries: make([]AudienceDelivery, len(cmrs))}
+	for i, r := range c.CreateMessages(ctx, cmrs) {
+		result.Deliveries[i] = AudienceDelivery{Recipients: groups[i], MessageID: r.MessageID, Err: r.Err}
+	}
+	return result, nil
+}
+
+// resolveAudience 返回去重并去掉排除成员后的接收人，保持首次出现的顺序
+func (c *Client) resolveAudience(ctx context.Context, audience *Audience) ([]string, error) {
+	excluded := make(map[string]bool, len(audience.Exclude))
+	for _, openID := range audience.Exclude {
+		excluded[openID] = true
+	}
+	for _, segment := range audience.ExcludeSegments {
+		openIDs, err := segment(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, openID := range openIDs {
+			excluded[openID] = true
+		}
+	}
+
+	var recipients []string
+	add := func(openIDs []string) {
+		for _, openID := range openIDs {
+			if openID != "" && !excluded[openID] {
+				excluded[openID] = true
+				recipients = append(recipients, openID)
+			}
+		}
+	}
+
+	add(audience.Recipients)
+	for _, segment := range audience.Segments {
+		openIDs, err := segment(ctx)
+		if err != nil {
+			return nil, err
+		}
+		add(openIDs)
+	}
+
+	if audience.All {
+		it := c.IterateMembers(ctx, MemberFilter{})
+		for it.Next() {
+			add([]string{it.Member().OpenID})
+		}
+		if err := it.Err(); err != nil {
+			return nil, err
+		}
+	}
+	return recipients, nil
+}
@@ -0,0 +1,107 @@
+package go_sdk
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestSendToAudience(t *testing.T) {
+	var mu sync.Mutex
+	var sent []*CreateMessageRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var data interface{}
+		switch r.URL.Path {
+		case "/v1/members":
+			data = MemberPage{Members: []*MemberProfile{{OpenID: "u1"}, {OpenID: "u2"}, {OpenID: "u3"}, {OpenID: "u4"}}}
+		case "/v1/messages/batch":
+			req := &createMessagesRequest{}
+			_ = json.NewDecoder(r.Body).Decode(req)
+			results := make([]map[string]interface{}, len(req.Messages))
+			mu.Lock()
+			for i, m := range req.Messages {
+				sent = append(sent, m)
+				results[i] = map[string]interface{}{"id": len(sent)}
+			}
+			mu.Unlock()
+			data = map[string]interface{}{"results": results}
+		case "/v1/messages":
+			req := &CreateMessageRequest{}
+			_ = json.NewDecoder(r.Body).Decode(req)
+			mu.Lock()
+			sent = append(sent, req)
+			mu.Unlock()
+			data = map[string]interface{}{"id": 100}
+		}
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"code": 0, "data": data})
+	}))
+	defer server.Close()
+
+	content := MessageContentRequest{TemplateID: "t1", TemplateVersion: 1, Title: "title", Data: map[string]interface{}{"n": 0}}
+
+	Convey("Send to all members without exclusions uses ToAll", t, func() {
+		sent = nil
+		client := NewClient("token", nil, WithAPIHost(server.URL))
+
+		result, err := client.SendToAudience(context.Background(), &AudienceMessage{Audience: Audience{All: true}, MessageContentRequest: content})
+		So(err, ShouldBeNil)
+		So(result.Deliveries, ShouldHaveLength, 1)
+		So(result.Deliveries[0].MessageID, ShouldEqual, 100)
+		So(sent[0].ToAll, ShouldBeTrue)
+	})
+
+	Convey("Exclusions expand members and chunk recipients", t, func() {
+		sent = nil
+		client := NewClient("token", nil, WithAPIHost(server.URL), WithMaxRecipients(2))
+
+		result, err := client.SendToAudience(context.Background(), &AudienceMessage{
+			Audience: Audience{
+				Recipients:      []string{"u5"},
+				Segments:        []Segment{StaticSegment("u6", "u5")},
+				All:             true,
+				Exclude:         []string{"u2"},
+				ExcludeSegments: []Segment{StaticSegment("u4")},
+			},
+			MessageContentRequest: content,
+		})
+		So(err, ShouldBeNil)
+		So(result.Failed(), ShouldBeEmpty)
+		So(result.Deliveries, ShouldHaveLength, 2)
+		So(result.Deliveries[0].Recipients, ShouldResemble, []string{"u5", "u6"})
+		So(result.Deliveries[1].Recipients, ShouldResemble, []string{"u1", "u3"})
+		So(sent, ShouldHaveLength, 2)
+	})
+
+	Convey("Personalize data per recipient", t, func() {
+		sent = nil
+		client := NewClient("token", nil, WithAPIHost(server.URL))
+
+		result, err := client.SendToAudience(context.Background(), &AudienceMessage{
+			Audience:              Audience{Recipients: []string{"u1", "u2"}},
+			MessageContentRequest: content,
+			Personalize: func(openID string) (map[string]interface{}, error) {
+				return map[string]interface{}{"name": openID}, nil
+			},
+		})
+		So(err, ShouldBeNil)
+		So(result.Deliveries, ShouldHaveLength, 2)
+		So(sent[0].Recipients, ShouldResemble, []string{"u1"})
+		So(sent[0].Data["name"], ShouldEqual, "u1")
+		So(sent[1].Data["name"], ShouldEqual, "u2")
+	})
+
+	Convey("Everyone excluded", t, func() {
+		client := NewClient("token", nil, WithAPIHost(server.URL))
+
+		_, err := client.SendToAudience(context.Background(), &AudienceMessage{
+			Audience:              Audience{Recipients: []string{"u1"}, Exclude: []string{"u1"}},
+			MessageContentRequest: content,
+		})
+		So(err, ShouldEqual, ErrNoRecipients)
+	})
+}
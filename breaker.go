@@ -0,0 +1,200 @@
+package go_sdk
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/patrickmn/go-cache"
+)
+
+var (
+	ErrCircuitOpen = errors.New("circuit breaker is open")
+)
+
+// BreakerState 表示熔断器的状态
+type BreakerState int
+
+const (
+	// BreakerClosed 正常状态，请求正常发送
+	BreakerClosed BreakerState = iota
+	// BreakerOpen 熔断状态，请求直接返回 ErrCircuitOpen
+	BreakerOpen
+	// BreakerHalfOpen 熔断一段时间后进入半开状态，允许少量请求试探平台接口是否恢复
+	BreakerHalfOpen
+)
+
+func (s BreakerState) String() string {
+	switch s {
+	case BreakerClosed:
+		return "closed"
+	case BreakerOpen:
+		return "open"
+	case BreakerHalfOpen:
+		return "half-open"
+	}
+	return "unknown"
+}
+
+// CircuitBreaker 是一个熔断器，平台接口连续失败 failureThreshold 次后进入熔断状态，此后的请求立即失败，
+// 避免每个请求都等待超时；熔断 openTimeout 后进入半开状态，最多允许 halfOpenMaxRequests 个请求试探，
+// 试探请求全部成功则恢复正常，任意一个失败则重新熔断。
+// 只有网络问题、服务器故障等临时性错误计为失败，业务性错误（APIError）说明平台接口是可用的
+type CircuitBreaker struct {
+	failureThreshold    int
+	openTimeout         time.Duration
+	halfOpenMaxRequests int
+
+	mutex     sync.Mutex
+	state     BreakerState
+	failures  int
+	openedAt  time.Time
+	inFlight  int
+	successes int
+	// 每次状态变化时递增，用于忽略状态变化之前发出的请求的结果
+	generation uint64
+}
+
+func NewCircuitBreaker(failureThreshold int, openTimeout time.Duration, halfOpenMaxRequests int) *CircuitBreaker {
+	if failureThreshold < 1 {
+		failureThreshold = 1
+	}
+
+	if halfOpenMaxRequests < 1 {
+		halfOpenMaxRequests = 1
+	}
+
+	return &CircuitBreaker{
+		failureThreshold:    failureThreshold,
+		openTimeout:         openTimeout,
+		halfOpenMaxRequests: halfOpenMaxRequests,
+	}
+}
+
+// State 返回熔断器当前的状态
+func (b *CircuitBreaker) State() BreakerState {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	b.refresh(time.Now())
+	return b.state
+}
+
+func (b *CircuitBreaker) refresh(now time.Time) {
+	if b.state == BreakerOpen && now.Sub(b.openedAt) >= b.openTimeout {
+		b.setState(BreakerHalfOpen, now)
+	}
+}
+
+func (b *CircuitBreaker) setState(state BreakerState, now time.Time) {
+	b.state = state
+	b.generation++
+	b.failures = 0
+	b.inFlight = 0
+	b.successes = 0
+	if state == BreakerOpen {
+		b.openedAt = now
+	}
+}
+
+// allow 返回请求是否可以发送，可以发送时返回当前的 generation，请求结束后需要调用 done
+func (b *CircuitBreaker) allow() (uint64, error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	b.refresh(time.Now())
+	switch b.state {
+	case BreakerOpen:
+		return 0, ErrCircuitOpen
+	case BreakerHalfOpen:
+		if b.inFlight >= b.halfOpenMaxRequests {
+			return 0, ErrCircuitOpen
+		}
+		b.inFlight++
+	}
+
+	return b.generation, nil
+}
+
+// release 释放 allow 占用的试探名额，用于请求没有发出的情况，不记录结果
+func (b *CircuitBreaker) release(generation uint64) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if generation == b.generation && b.state == BreakerHalfOpen && b.inFlight > 0 {
+		b.inFlight--
+	}
+}
+
+// done 记录请求的结果
+func (b *CircuitBreaker) done(generation uint64, failed bool) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if generation != b.generation {
+		return
+	}
+
+	now := time.Now()
+	switch b.state {
+	case BreakerClosed:
+		if !failed {
+			b.failures = 0
+			return
+		}
+
+		b.failures++
+		if b.failures >= b.failureThreshold {
+			b.setState(BreakerOpen, now)
+		}
+	case BreakerHalfOpen:
+		if failed {
+			b.setState(BreakerOpen, now)
+			return
+		}
+
+		b.successes++
+		if b.successes >= b.halfOpenMaxRequests {
+			b.setState(BreakerClosed, now)
+		}
+	}
+}
+
+// WithCircuitBreaker 为 Client 设置熔断器，熔断状态下所有接口立即返回 ErrCircuitOpen
+func WithCircuitBreaker(b *CircuitBreaker) ClientOption {
+	return func(c *Client) {
+		c.breaker = b
+	}
+}
+
+// WithStaleMembers 设置熔断状态下，VerifyRequestToken 返回已过期不超过 maxStale 的成员信息，
+// 避免平台接口故障期间所有用户都无法操作。成员信息在每次验证成功后保存在内存中，与 RequestTokenCache 无关
+func WithStaleMembers(maxStale time.Duration) ClientOption {
+	return func(c *Client) {
+		c.maxStale = maxStale
+		c.staleMembers = cache.New(maxStale, 10*time.Minute)
+	}
+}
+
+func (c *Client) saveStaleMember(requestToken string, m Member) {
+	if c.staleMembers == nil {
+		return
+	}
+
+	d := time.Second*time.Duration(m.ExpiredAt-time.Now().Unix()) + c.maxStale
+	if d > 0 {
+		c.staleMembers.Set(requestToken, m, d)
+	}
+}
+
+func (c *Client) staleMember(requestToken string) (m Member, ok bool) {
+	if c.staleMembers == nil {
+		return
+	}
+
+	v, ok := c.staleMembers.Get(requestToken)
+	if !ok {
+		return
+	}
+	return v.(Member), true
+}
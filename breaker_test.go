@@ -0,0 +1,103 @@
+package go_sdk
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	Convey("Breaker opens after consecutive failures and recovers", t, func() {
+		b := NewCircuitBreaker(2, 20*time.Millisecond, 1)
+
+		g, err := b.allow()
+		So(err, ShouldBeNil)
+		b.done(g, true)
+		So(b.State(), ShouldEqual, BreakerClosed)
+
+		g, _ = b.allow()
+		b.done(g, true)
+		So(b.State(), ShouldEqual, BreakerOpen)
+
+		_, err = b.allow()
+		So(err, ShouldEqual, ErrCircuitOpen)
+
+		time.Sleep(20 * time.Millisecond)
+		So(b.State(), ShouldEqual, BreakerHalfOpen)
+
+		g, err = b.allow()
+		So(err, ShouldBeNil)
+		_, err = b.allow()
+		So(err, ShouldEqual, ErrCircuitOpen)
+
+		b.done(g, false)
+		So(b.State(), ShouldEqual, BreakerClosed)
+	})
+
+	Convey("Requests that are never sent do not count", t, func() {
+		b := NewCircuitBreaker(2, 20*time.Millisecond, 1)
+		client := NewClient("", nil, WithCircuitBreaker(b))
+
+		g, _ := b.allow()
+		b.done(g, true)
+		So(client.DeleteMessage(1), ShouldEqual, ErrAccessTokenRequired)
+		g, _ = b.allow()
+		b.done(g, true)
+		So(b.State(), ShouldEqual, BreakerOpen)
+
+		time.Sleep(20 * time.Millisecond)
+		So(client.DeleteMessage(1), ShouldEqual, ErrAccessTokenRequired)
+		So(b.State(), ShouldEqual, BreakerHalfOpen)
+
+		g, err := b.allow()
+		So(err, ShouldBeNil)
+		b.done(g, false)
+		So(b.State(), ShouldEqual, BreakerClosed)
+	})
+}
+
+func TestVerifyRequestTokenWithStaleMembers(t *testing.T) {
+	var down int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.LoadInt32(&down) == 1 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"code": 0,
+			"data": Member{OpenID: "u1", ExpiredAt: time.Now().Unix() + 1},
+		})
+	}))
+	defer server.Close()
+
+	originHost := apiHost
+	apiHost = server.URL
+	defer func() { apiHost = originHost }()
+
+	Convey("Stale members are served while the breaker is open", t, func() {
+		client := NewClient("token", nil,
+			WithCircuitBreaker(NewCircuitBreaker(1, time.Minute, 1)),
+			WithStaleMembers(time.Minute))
+
+		m, err := client.VerifyRequestToken("rt")
+		So(err, ShouldBeNil)
+		So(m.OpenID, ShouldEqual, "u1")
+
+		atomic.StoreInt32(&down, 1)
+		_, err = client.VerifyRequestToken("rt")
+		So(err, ShouldResemble, &StatusError{StatusCode: http.StatusBadGateway})
+
+		m, err = client.VerifyRequestToken("rt")
+		So(err, ShouldBeNil)
+		So(m.OpenID, ShouldEqual, "u1")
+
+		_, err = client.VerifyRequestToken("unknown")
+		So(err, ShouldEqual, ErrCircuitOpen)
+	})
+}
@@ -2,13 +2,14 @@ package go_sdk
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
-	"fmt"
 	"net/http"
 	"os"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/patrickmn/go-cache"
@@ -63,9 +64,93 @@ func (c *MemoryCache) Delete(rt string) {
 
 // Client 构建了几个与平台服务端接口进行交互的方法
 type Client struct {
+	apiHost     string
 	pathPrefix  string
-	accessToken string
+	tokenSource TokenSource
+	authCodes   map[int]bool
 	cache       RequestTokenCache
+
+	// 鉴权失败的 access token 以及重试成功的备用 token，TokenSource 仍然返回 rejectedToken 时直接使用 fallbackToken
+	tokenMutex    sync.Mutex
+	rejectedToken string
+	fallbackToken string
+
+	// 批量接口每次请求的消息数量及并发请求数
+	batchSize   int
+	concurrency int
+	// 批量接口返回 404/405 后，在此时间（UnixNano）之前不再尝试批量接口
+	batchUnsupportedUntil int64
+	// SendToAudience 每条消息的最大接收人数量
+	maxRecipients int
+
+	// 各分组接口的限流器
+	limiterMutex   sync.Mutex
+	limiters       map[Endpoint]*RateLimiter
+	rateLimitMode  RateLimitMode
+	rateLimitCodes map[int]bool
+
+	// 熔断器以及熔断时使用的过期成员信息
+	breaker      *CircuitBreaker
+	maxStale     time.Duration
+	staleMembers *cache.Cache
+
+	// 缓存即将过期时在后台重新验证 request token
+	revalidateWindow time.Duration
+	revalidating     *cache.Cache
+
+	// 成员资料缓存
+	profiles *cache.Cache
+
+	httpClient *http.Client
+	logger     Logger
+	metrics    Metrics
+	tracer     Tracer
+}
+
+// ClientOption 用于设置 Client 的可选参数
+type ClientOption func(c *Client)
+
+// WithBatchSize 设置批量创建消息时每次请求包含的消息数量，默认为 100
+func WithBatchSize(n int) ClientOption {
+	return func(c *Client) {
+		if n > 0 {
+			c.batchSize = n
+		}
+	}
+}
+
+// WithConcurrency 设置批量操作时的最大并发请求数，默认为 8
+func WithConcurrency(n int) ClientOption {
+	return func(c *Client) {
+		if n > 0 {
+			c.concurrency = n
+		}
+	}
+}
+
+// WithStaleWhileRevalidate 设置 VerifyRequestToken 在缓存的成员信息距离过期不足 window 时，
+// 仍然立即返回缓存的信息，同时在后台重新验证 request token 并更新缓存，活跃用户的请求不会因为
+// 缓存过期而等待平台接口。需要同时设置 RequestTokenCache
+func WithStaleWhileRevalidate(window time.Duration) ClientOption {
+	return func(c *Client) {
+		c.revalidateWindow = window
+		c.revalidating = cache.New(window, 10*time.Minute)
+	}
+}
+
+// WithAPIHost 设置平台接口的地址，默认为 https://api.super-message.com，也可以通过环境变量 SM_API 设置
+func WithAPIHost(host string) ClientOption {
+	return func(c *Client) {
+		c.apiHost = strings.TrimRight(strings.TrimSpace(host), "/")
+	}
+}
+
+// WithHTTPClient 设置调用平台接口使用的 http.Client，默认为 http.DefaultClient，
+// 可以用于设置超时、代理，或者让多个 Client 共享连接池
+func WithHTTPClient(hc *http.Client) ClientOption {
+	return func(c *Client) {
+		c.httpClient = hc
+	}
 }
 
 // NewClient 新建一个 Client 实例，其中 accessToken 为 Channel 访问平台接口的 token，
@@ -77,12 +162,33 @@ type Client struct {
 //      client := NewClient("accessToken", NewMemoryCache())
 // 不使用缓存：
 //      client := NewClient("accessToken", nil)
-func NewClient(accessToken string, cache RequestTokenCache) *Client {
-	return &Client{
+// 需要在不重启服务的情况下轮换 access token 时，通过 WithTokenSource 设置 access token 的来源。
+// 其它可选参数通过 opts 设置：
+//      client := NewClient("accessToken", NewMemoryCache(), WithConcurrency(16))
+func NewClient(accessToken string, cache RequestTokenCache, opts ...ClientOption) *Client {
+	c := &Client{
 		pathPrefix:  "/v1",
-		accessToken: accessToken,
+		authCodes:   make(map[int]bool),
 		cache:       cache,
+		batchSize:   100,
+		concurrency: 8,
+
+		maxRecipients: 1000,
+
+		limiters:       make(map[Endpoint]*RateLimiter),
+		rateLimitCodes: make(map[int]bool),
+
+		profiles: newProfileCache(defaultProfileTTL),
+	}
+
+	for _, opt := range opts {
+		opt(c)
 	}
+
+	if c.tokenSource == nil {
+		c.tokenSource = StaticTokenSource(accessToken)
+	}
+	return c
 }
 
 var (
@@ -91,25 +197,154 @@ var (
 	ErrInvalidTemplateVersion = errors.New("invalid template version, version number must be greater than 0")
 	ErrMessageTitleRequired   = errors.New("message title is required")
 	ErrMessageIDRequired      = errors.New("message id is required")
+	ErrEmptyUpdatePart        = errors.New("update part has no operations")
 )
 
 func (c *Client) apiURL(path string) string {
+	if c.apiHost != "" {
+		return c.apiHost + c.pathPrefix + path
+	}
 	return apiHost + c.pathPrefix + path
 }
 
-func (c *Client) doRequest(req *http.Request, expected interface{}) error {
-	if c.accessToken == "" {
-		return ErrAccessTokenRequired
+// doRequest 发送请求，path 为传给 apiURL 的接口路径，用于确定限流和统计所属的接口分组
+func (c *Client) doRequest(req *http.Request, path string, expected interface{}) (err error) {
+	start := time.Now()
+	endpoint := endpointOf(path)
+	query := redactQuery(req.URL.Query())
+	status := 0
+	token := ""
+
+	ctx, span := c.trace().Start(req.Context(), req.Method+" "+req.URL.Path, SpanKindClient, map[string]interface{}{
+		AttrHTTPMethod: req.Method,
+		AttrHTTPPath:   req.URL.Path,
+		AttrEndpoint:   string(endpoint),
+	})
+	req = req.WithContext(ctx)
+	c.trace().Inject(ctx, req.Header)
+
+	defer func() {
+		latency := time.Since(start)
+		c.logRequest(req.Method, req.URL.Path, query, token, status, latency, err)
+
+		code := 0
+		if re, ok := err.(*APIError); ok {
+			code = re.Code
+		}
+		c.observer().ObserveRequest(endpoint, req.Method, status, code, latency)
+
+		span.SetAttributes(map[string]interface{}{AttrHTTPStatus: status, AttrErrorCode: code})
+		span.End(err)
+	}()
+
+	if c.breaker == nil {
+		status, token, _, err = c.do(req, endpoint, expected)
+		return
+	}
+
+	generation, err := c.breaker.allow()
+	if err != nil {
+		return
+	}
+
+	var sent bool
+	status, token, sent, err = c.do(req, endpoint, expected)
+	if !sent {
+		// 没有获取到 token、限流等待失败时请求没有发出，不能说明平台接口的状态
+		c.breaker.release(generation)
+		return
 	}
 
-	req.URL.RawQuery += "&accessToken=" + c.accessToken
-	apiResponse, err := http.DefaultClient.Do(req)
+	// 请求被取消以及被限流不能说明平台接口出现了故障
+	failed := err != nil && isTemporaryError(err) && !c.isRateLimitError(err) && req.Context().Err() == nil
+	c.breaker.done(generation, failed)
+	return
+}
+
+// do 发送请求，返回平台接口响应的 HTTP 状态码（请求未发出时为 0）、使用的 access token 以及请求是否已经发出，
+// access token 鉴权失败时使用 TokenSource 提供的备用 token 重试一次
+func (c *Client) do(req *http.Request, endpoint Endpoint, expected interface{}) (int, string, bool, error) {
+	current, err := c.tokenSource.Token()
 	if err != nil {
-		return err
+		return 0, "", false, err
+	}
+	if current == "" {
+		return 0, "", false, ErrAccessTokenRequired
+	}
+	token := c.preferredToken(current)
+
+	if err = c.waitRateLimit(req.Context(), endpoint); err != nil {
+		return 0, token, false, err
+	}
+
+	status, err := c.send(req, endpoint, expected, token)
+	if !c.isAuthError(err) {
+		return status, token, true, err
+	}
+
+	fallback, ferr := c.tokenSource.Fallback(token)
+	if ferr != nil || fallback == "" || fallback == token {
+		return status, token, true, err
+	}
+
+	retry, rerr := replayRequest(req)
+	if rerr != nil {
+		return status, token, true, err
+	}
+
+	c.log().Warn("access token rejected, retrying with fallback token",
+		"accessToken", redact(token), "fallback", redact(fallback), "error", err.Error())
+	if err = c.waitRateLimit(req.Context(), endpoint); err != nil {
+		return status, token, true, err
+	}
+
+	status, err = c.send(retry, endpoint, expected, fallback)
+	if _, ok := err.(*APIError); err == nil || ok && !c.isAuthError(err) {
+		c.rememberToken(current, fallback)
+	}
+	return status, fallback, true, err
+}
+
+// replayRequest 复制请求用于重试，请求体通过 GetBody 重新获取
+func replayRequest(req *http.Request) (*http.Request, error) {
+	retry := req.WithContext(req.Context())
+	if req.Body == nil || req.Body == http.NoBody {
+		return retry, nil
+	}
+
+	if req.GetBody == nil {
+		return nil, errors.New("request body can not be replayed")
+	}
+
+	body, err := req.GetBody()
+	if err != nil {
+		return nil, err
+	}
+	retry.Body = body
+	return retry, nil
+}
+
+// send 使用 token 发送一次请求
+func (c *Client) send(req *http.Request, endpoint Endpoint, expected interface{}, token string) (int, error) {
+	u := *req.URL
+	u.RawQuery += "&accessToken=" + token
+	req = req.WithContext(req.Context())
+	req.URL = &u
+
+	hc := c.httpClient
+	if hc == nil {
+		hc = http.DefaultClient
+	}
+
+	apiResponse, err := hc.Do(req)
+	if err != nil {
+		return 0, err
 	}
+	defer apiResponse.Body.Close()
 
 	if apiResponse.StatusCode != http.StatusOK {
-		return fmt.Errorf("the server api responses an unexpected status code, expect 200, but got %d", apiResponse.StatusCode)
+		c.adaptRateLimit(endpoint, apiResponse, 0)
+		return apiResponse.StatusCode, &StatusError{StatusCode: apiResponse.StatusCode}
 	}
 
 	rs := &response{
@@ -118,14 +353,35 @@ func (c *Client) doRequest(req *http.Request, expected interface{}) error {
 
 	decoder := json.NewDecoder(apiResponse.Body)
 	if err = decoder.Decode(rs); err != nil {
-		return err
+		return apiResponse.StatusCode, err
 	}
-	_ = apiResponse.Body.Close()
-	if rs.Code != 0 {
-		return rs.APIError
+	if rs.APIError != nil && rs.Code != 0 {
+		c.adaptRateLimit(endpoint, apiResponse, rs.Code)
+		return apiResponse.StatusCode, rs.APIError
 	}
 
-	return nil
+	c.adaptRateLimit(endpoint, apiResponse, 0)
+	return apiResponse.StatusCode, nil
+}
+
+func (c *Client) logRequest(method, path, query, token string, status int, latency time.Duration, err error) {
+	args := []interface{}{
+		"method", method,
+		"path", path,
+		"query", query,
+		"status", status,
+		"latency", latency,
+		"accessToken", redact(token),
+	}
+
+	switch e := err.(type) {
+	case nil:
+		c.log().Debug("platform api request", args...)
+	case *APIError:
+		c.log().Warn("platform api request", append(args, "code", e.Code, "error", e.Message)...)
+	default:
+		c.log().Error("platform api request", append(args, "error", err.Error())...)
+	}
 }
 
 func (c *Client) Cache() RequestTokenCache {
@@ -143,25 +399,73 @@ func (c *Client) Cache() RequestTokenCache {
 //      requestToken 有一个生命周期，在一个周期内，同个用户的操作请求中 requestToken 保持不变
 //      ，建议缓存此数据
 func (c *Client) VerifyRequestToken(requestToken string) (m Member, err error) {
+	return c.VerifyRequestTokenContext(context.Background(), requestToken)
+}
+
+// VerifyRequestTokenContext 与 VerifyRequestToken 相同，ctx 用于控制请求的取消和超时
+func (c *Client) VerifyRequestTokenContext(ctx context.Context, requestToken string) (m Member, err error) {
+	ctx, span := c.trace().Start(ctx, "sm.VerifyRequestToken", SpanKindInternal, nil)
+	defer func() { span.End(err) }()
+
 	if c.cache != nil {
 		m, exist := c.cache.Get(requestToken)
+		c.observer().ObserveCache(exist)
+		span.SetAttributes(map[string]interface{}{AttrCacheHit: exist})
 		if exist {
+			if c.revalidateWindow > 0 && time.Unix(m.ExpiredAt, 0).Sub(time.Now()) < c.revalidateWindow {
+				c.revalidate(requestToken)
+			}
 			return m, nil
 		}
 	}
 
+	return c.verify(ctx, requestToken)
+}
+
+// revalidate 在后台重新验证 request token，同一个 token 在 window/4（至少 1 秒）内只会验证一次，
+// 避免平台返回的过期时间没有变化时，每个请求都触发一次验证
+func (c *Client) revalidate(requestToken string) {
+	interval := c.revalidateWindow / 4
+	if interval < time.Second {
+		interval = time.Second
+	}
+
+	if err := c.revalidating.Add(requestToken, true, interval); err != nil {
+		return
+	}
+
+	go func() {
+		_, err := c.verify(context.Background(), requestToken)
+		if re, ok := err.(*APIError); ok && re.IsInvalidRequestToken() {
+			c.cache.Delete(requestToken)
+		} else if err != nil {
+			c.log().Warn("failed to revalidate request token", "requestToken", redact(requestToken), "error", err.Error())
+		}
+	}()
+}
+
+// verify 调用平台接口验证 request token，验证成功后更新缓存
+func (c *Client) verify(ctx context.Context, requestToken string) (m Member, err error) {
 	req, err := http.NewRequest("GET", c.apiURL("/user/verify")+"?token="+requestToken, nil)
 	if err != nil {
 		return
 	}
 
 	expected := &Member{}
-	err = c.doRequest(req, expected)
+	err = c.doRequest(req.WithContext(ctx), "/user/verify", expected)
 	if err != nil {
+		if err == ErrCircuitOpen {
+			if stale, ok := c.staleMember(requestToken); ok {
+				return stale, nil
+			}
+		}
 		return
 	}
 
 	m = *expected
+	if m.OpenID != "" {
+		c.saveStaleMember(requestToken, m)
+	}
 	if m.OpenID != "" && m.ExpiredAt-10 > time.Now().Unix() && c.cache != nil {
 		_ = c.cache.Set(requestToken, m)
 	}
@@ -213,7 +517,21 @@ type createMessageResponse struct {
 // CreateMessage 通过平台向频道或指定用户推送消息
 // err 参考 VerifyRequestToken 接口 error 的处理方法
 func (c *Client) CreateMessage(cmr *CreateMessageRequest) (messageID int64, err error) {
-	if err := cmr.check(); err != nil {
+	return c.CreateMessageContext(context.Background(), cmr)
+}
+
+// CreateMessageContext 与 CreateMessage 相同，ctx 用于控制请求的取消和超时
+func (c *Client) CreateMessageContext(ctx context.Context, cmr *CreateMessageRequest) (messageID int64, err error) {
+	attrs := contentAttributes(&cmr.MessageContentRequest)
+	attrs[AttrRecipientCount] = len(cmr.Recipients)
+	attrs[AttrToAll] = cmr.ToAll
+	ctx, span := c.trace().Start(ctx, "sm.CreateMessage", SpanKindInternal, attrs)
+	defer func() {
+		span.SetAttributes(map[string]interface{}{AttrMessageID: messageID})
+		span.End(err)
+	}()
+
+	if err = cmr.check(); err != nil {
 		return 0, err
 	}
 
@@ -229,8 +547,9 @@ func (c *Client) CreateMessage(cmr *CreateMessageRequest) (messageID int64, err
 		return
 	}
 
+	req = req.WithContext(ctx)
 	expected := &createMessageResponse{}
-	err = c.doRequest(req, expected)
+	err = c.doRequest(req, "/messages", expected)
 	if err != nil {
 		return
 	}
@@ -246,11 +565,21 @@ type UpdateMessageRequest struct {
 
 // UpdateMessage 更新已有消息的内容，包括模版、标题和数据，不能原消息修改接收人
 func (c *Client) UpdateMessage(umr *UpdateMessageRequest) (err error) {
+	return c.UpdateMessageContext(context.Background(), umr)
+}
+
+// UpdateMessageContext 与 UpdateMessage 相同，ctx 用于控制请求的取消和超时
+func (c *Client) UpdateMessageContext(ctx context.Context, umr *UpdateMessageRequest) (err error) {
+	attrs := contentAttributes(&umr.MessageContentRequest)
+	attrs[AttrMessageID] = umr.ID
+	ctx, span := c.trace().Start(ctx, "sm.UpdateMessage", SpanKindInternal, attrs)
+	defer func() { span.End(err) }()
+
 	if umr.ID <= 0 {
 		return ErrMessageIDRequired
 	}
 
-	if err := umr.MessageContentRequest.check(); err != nil {
+	if err = umr.MessageContentRequest.check(); err != nil {
 		return err
 	}
 
@@ -266,7 +595,7 @@ func (c *Client) UpdateMessage(umr *UpdateMessageRequest) (err error) {
 		return
 	}
 
-	err = c.doRequest(req, nil)
+	err = c.doRequest(req.WithContext(ctx), "/messages", nil)
 	if err != nil {
 		return
 	}
@@ -274,8 +603,57 @@ func (c *Client) UpdateMessage(umr *UpdateMessageRequest) (err error) {
 	return
 }
 
+type patchMessageRequest struct {
+	ID int64 `json:"id"`
+	*UpdatePart
+}
+
+// PatchMessage 对已有消息的数据执行 UpdatePart 中的操作，只修改部分字段，不需要知道消息完整的数据，
+// 比如后台任务更新订单状态：
+//      ops := NewUpdatePart()
+//      ops.AddOpSet(NewSet().Add("order.status", "shipped"))
+//      err := client.PatchMessage(messageID, ops)
+func (c *Client) PatchMessage(messageID int64, ops *UpdatePart) (err error) {
+	return c.PatchMessageContext(context.Background(), messageID, ops)
+}
+
+// PatchMessageContext 与 PatchMessage 相同，ctx 用于控制请求的取消和超时
+func (c *Client) PatchMessageContext(ctx context.Context, messageID int64, ops *UpdatePart) (err error) {
+	ctx, span := c.trace().Start(ctx, "sm.PatchMessage", SpanKindInternal, map[string]interface{}{AttrMessageID: messageID})
+	defer func() { span.End(err) }()
+
+	if messageID <= 0 {
+		return ErrMessageIDRequired
+	}
+
+	if ops == nil || len(ops.Ops) == 0 {
+		return ErrEmptyUpdatePart
+	}
+
+	body := new(bytes.Buffer)
+	err = json.NewEncoder(body).Encode(&patchMessageRequest{ID: messageID, UpdatePart: ops})
+	if err != nil {
+		return
+	}
+
+	req, err := http.NewRequest("PATCH", c.apiURL("/messages"), body)
+	if err != nil {
+		return
+	}
+
+	return c.doRequest(req.WithContext(ctx), "/messages", nil)
+}
+
 // DeleteMessage 删除一条已有的消息
 func (c *Client) DeleteMessage(messageID int64) (err error) {
+	return c.DeleteMessageContext(context.Background(), messageID)
+}
+
+// DeleteMessageContext 与 DeleteMessage 相同，ctx 用于控制请求的取消和超时
+func (c *Client) DeleteMessageContext(ctx context.Context, messageID int64) (err error) {
+	ctx, span := c.trace().Start(ctx, "sm.DeleteMessage", SpanKindInternal, map[string]interface{}{AttrMessageID: messageID})
+	defer func() { span.End(err) }()
+
 	if messageID <= 0 {
 		return ErrMessageIDRequired
 	}
@@ -286,7 +664,7 @@ func (c *Client) DeleteMessage(messageID int64) (err error) {
 		return
 	}
 
-	err = c.doRequest(req, nil)
+	err = c.doRequest(req.WithContext(ctx), "/messages", nil)
 	if err != nil {
 		return
 	}
@@ -0,0 +1,192 @@
+package go_sdk
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+var errMissingBatchResult = errors.New("missing result in batch response")
+
+// batchProbeInterval 为批量接口不可用后重新尝试的间隔，避免一次临时的路由错误永久关闭批量接口
+const batchProbeInterval = 10 * time.Minute
+
+// CreateMessageResult 为批量创建消息中单条消息的结果
+type CreateMessageResult struct {
+	MessageID int64
+	Err       error
+}
+
+type createMessagesRequest struct {
+	Messages []*CreateMessageRequest `json:"messages"`
+}
+
+type createMessagesResponse struct {
+	Results []struct {
+		*APIError
+		ID int64 `json:"id"`
+	} `json:"results"`
+}
+
+// CreateMessages 批量创建消息，返回的结果与 cmrs 一一对应。
+// 消息按 WithBatchSize 设置的数量分块，通过平台的批量接口发送，最多同时发起 WithConcurrency 设置的请求数；
+// 如果平台不支持批量接口，则并发地逐条调用 CreateMessage 接口，并在一段时间后重新尝试批量接口。
+// 每条消息的 err 参考 VerifyRequestToken 接口 error 的处理方法，ctx 取消后未发送的消息返回 ctx.Err()
+func (c *Client) CreateMessages(ctx context.Context, cmrs []*CreateMessageRequest) []CreateMessageResult {
+	results := make([]CreateMessageResult, len(cmrs))
+
+	ctx, span := c.trace().Start(ctx, "sm.CreateMessages", SpanKindInternal, map[string]interface{}{AttrMessageCount: len(cmrs)})
+	defer func() {
+		failed := 0
+		for _, result := range results {
+			if result.Err != nil {
+				failed++
+			}
+		}
+		span.SetAttributes(map[string]interface{}{AttrFailedCount: failed})
+		span.End(nil)
+	}()
+
+	pending := make([]int, 0, len(cmrs))
+	for i, cmr := range cmrs {
+		if err := cmr.check(); err != nil {
+			results[i].Err = err
+			continue
+		}
+		pending = append(pending, i)
+	}
+
+	if time.Now().UnixNano() >= atomic.LoadInt64(&c.batchUnsupportedUntil) {
+		batchSize := c.batchSize
+		if batchSize < 1 {
+			batchSize = 1
+		}
+
+		var chunks [][]int
+		for start := 0; start < len(pending); start += batchSize {
+			end := start + batchSize
+			if end > len(pending) {
+				end = len(pending)
+			}
+			chunks = append(chunks, pending[start:end])
+		}
+
+		var mutex sync.Mutex
+		var fallback []int
+		c.parallel(ctx, len(chunks), func(i int, err error) {
+			chunk := chunks[i]
+			if err == nil {
+				err = c.createMessageBatch(ctx, cmrs, chunk, results)
+			}
+
+			if se, ok := err.(*StatusError); ok && (se.StatusCode == http.StatusNotFound || se.StatusCode == http.StatusMethodNotAllowed) {
+				atomic.StoreInt64(&c.batchUnsupportedUntil, time.Now().Add(batchProbeInterval).UnixNano())
+				mutex.Lock()
+				fallback = append(fallback, chunk...)
+				mutex.Unlock()
+				return
+			}
+
+			if err != nil {
+				for _, j := range chunk {
+					results[j].Err = err
+				}
+			}
+		})
+
+		pending = fallback
+	}
+
+	c.parallel(ctx, len(pending), func(i int, err error) {
+		j := pending[i]
+		if err == nil {
+			results[j].MessageID, err = c.CreateMessageContext(ctx, cmrs[j])
+		}
+		results[j].Err = err
+	})
+
+	return results
+}
+
+func (c *Client) createMessageBatch(ctx context.Context, cmrs []*CreateMessageRequest, chunk []int, results []CreateMessageResult) error {
+	cmr := &createMessagesRequest{Messages: make([]*CreateMessageRequest, len(chunk))}
+	recipients := 0
+	for i, j := range chunk {
+		cmr.Messages[i] = cmrs[j]
+		recipients += len(cmrs[j].Recipients)
+	}
+
+	ctx, span := c.trace().Start(ctx, "sm.CreateMessageBatch", SpanKindInternal, map[string]interface{}{
+		AttrMessageCount:   len(chunk),
+		AttrRecipientCount: recipients,
+	})
+	var err error
+	defer func() { span.End(err) }()
+
+	body := new(bytes.Buffer)
+	if err = json.NewEncoder(body).Encode(cmr); err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest("POST", c.apiURL("/messages/batch"), body)
+	if err != nil {
+		return err
+	}
+
+	expected := &createMessagesResponse{}
+	if err = c.doRequest(req.WithContext(ctx), "/messages/batch", expected); err != nil {
+		return err
+	}
+
+	for i, j := range chunk {
+		if i >= len(expected.Results) {
+			results[j].Err = errMissingBatchResult
+			continue
+		}
+
+		result := expected.Results[i]
+		if result.APIError != nil && result.Code != 0 {
+			results[j].Err = result.APIError
+			continue
+		}
+		results[j].MessageID = result.ID
+	}
+
+	return nil
+}
+
+// parallel 以最多 c.concurrency 个 goroutine 并发执行 fn(0) ~ fn(n-1)，
+// ctx 取消后尚未执行的 fn 将收到 ctx.Err()
+func (c *Client) parallel(ctx context.Context, n int, fn func(i int, err error)) {
+	concurrency := c.concurrency
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	sem := make(chan struct{}, concurrency)
+	wg := sync.WaitGroup{}
+	for i := 0; i < n; i++ {
+		select {
+		case <-ctx.Done():
+			fn(i, ctx.Err())
+			continue
+		case sem <- struct{}{}:
+		}
+
+		wg.Add(1)
+		go func(i int) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			fn(i, nil)
+		}(i)
+	}
+
+	wg.Wait()
+}
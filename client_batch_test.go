@@ -0,0 +1,114 @@
+package go_sdk
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func newBatchRequests(n int) []*CreateMessageRequest {
+	cmrs := make([]*CreateMessageRequest, n)
+	for i := range cmrs {
+		cmrs[i] = &CreateMessageRequest{
+			Recipients: []string{fmt.Sprint(i)},
+			MessageContentRequest: MessageContentRequest{
+				TemplateID:      "t1",
+				TemplateVersion: 1,
+				Title:           "title",
+			},
+		}
+	}
+	return cmrs
+}
+
+func TestCreateMessages(t *testing.T) {
+	var batchCalls, singleCalls int64
+	batchSupported := true
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1/messages/batch":
+			if !batchSupported {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+
+			atomic.AddInt64(&batchCalls, 1)
+			req := &createMessagesRequest{}
+			_ = json.NewDecoder(r.Body).Decode(req)
+			results := make([]map[string]interface{}, len(req.Messages))
+			for i, m := range req.Messages {
+				if m.Recipients[0] == "3" {
+					results[i] = map[string]interface{}{"code": 20001, "message": "invalid recipient"}
+					continue
+				}
+				results[i] = map[string]interface{}{"id": 1000 + len(m.Recipients[0])}
+			}
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{"code": 0, "data": map[string]interface{}{"results": results}})
+		case "/v1/messages":
+			atomic.AddInt64(&singleCalls, 1)
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{"code": 0, "data": map[string]interface{}{"id": 1}})
+		}
+	}))
+	defer server.Close()
+
+	originHost := apiHost
+	apiHost = server.URL
+	defer func() { apiHost = originHost }()
+
+	Convey("Create messages through the batch endpoint", t, func() {
+		atomic.StoreInt64(&batchCalls, 0)
+		client := NewClient("token", nil, WithBatchSize(4), WithConcurrency(2))
+		cmrs := newBatchRequests(10)
+		cmrs[5].Title = ""
+
+		results := client.CreateMessages(context.Background(), cmrs)
+		So(len(results), ShouldEqual, 10)
+		So(atomic.LoadInt64(&batchCalls), ShouldEqual, 3)
+		So(results[0].Err, ShouldBeNil)
+		So(results[0].MessageID, ShouldEqual, 1001)
+		So(results[3].Err, ShouldHaveSameTypeAs, &APIError{})
+		So(results[5].Err, ShouldEqual, ErrMessageTitleRequired)
+	})
+
+	Convey("Fall back to single requests when batch is unsupported", t, func() {
+		batchSupported = false
+		atomic.StoreInt64(&singleCalls, 0)
+		client := NewClient("token", nil, WithBatchSize(4), WithConcurrency(2))
+
+		results := client.CreateMessages(context.Background(), newBatchRequests(10))
+		So(atomic.LoadInt64(&singleCalls), ShouldEqual, 10)
+		for _, r := range results {
+			So(r.Err, ShouldBeNil)
+			So(r.MessageID, ShouldEqual, 1)
+		}
+
+		Convey("Probe the batch endpoint again after the interval", func() {
+			batchSupported = true
+			atomic.StoreInt64(&batchCalls, 0)
+			client.CreateMessages(context.Background(), newBatchRequests(4))
+			So(atomic.LoadInt64(&batchCalls), ShouldEqual, 0)
+
+			atomic.StoreInt64(&client.batchUnsupportedUntil, time.Now().Add(-time.Second).UnixNano())
+			client.CreateMessages(context.Background(), newBatchRequests(4))
+			So(atomic.LoadInt64(&batchCalls), ShouldEqual, 1)
+		})
+	})
+
+	Convey("Zero batch size and concurrency do not block", t, func() {
+		batchSupported = true
+		atomic.StoreInt64(&batchCalls, 0)
+		client := NewClient("token", nil)
+		client.batchSize, client.concurrency = 0, 0
+
+		results := client.CreateMessages(context.Background(), newBatchRequests(3))
+		So(atomic.LoadInt64(&batchCalls), ShouldEqual, 3)
+		So(results[0].Err, ShouldBeNil)
+	})
+}
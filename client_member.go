@@ -0,0 +1,245 @@
+package go_sdk
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/patrickmn/go-cache"
+)
+
+var ErrOpenIDRequired = errors.New("open id is required")
+
+type memberContextKey struct{}
+
+// ContextWithMember 返回携带已验证成员的 context，验证 request token 的中间件可以用它将成员传给后续的处理函数
+func ContextWithMember(ctx context.Context, m Member) context.Context {
+	return context.WithValue(ctx, memberContextKey{}, m)
+}
+
+// MemberFromContext 返回 ContextWithMember 保存的成员，比如 Paginator 验证 request token 后传给 Fetch 的成员
+func MemberFromContext(ctx context.Context) (m Member, ok bool) {
+	m, ok = ctx.Value(memberContextKey{}).(Member)
+	return
+}
+
+// MemberProfile 为频道成员的资料
+type MemberProfile struct {
+	OpenID         string `json:"openID"`
+	Nickname       string `json:"nickname"`
+	Avatar         string `json:"avatar"`
+	ChannelCreator bool   `json:"channelCreator"`
+	// 订阅频道时间的 UNIX 时间戳
+	SubscribedAt int64 `json:"subscribedAt"`
+}
+
+const defaultProfileTTL = 5 * time.Minute
+
+func newProfileCache(ttl time.Duration) *cache.Cache {
+	if ttl <= 0 {
+		return nil
+	}
+	return cache.New(ttl, 10*time.Minute)
+}
+
+// WithMemberProfileTTL 设置 GetMember 缓存成员资料的时间，默认为 5 分钟，为 0 时不缓存
+func WithMemberProfileTTL(ttl time.Duration) ClientOption {
+	return func(c *Client) {
+		c.profiles = newProfileCache(ttl)
+	}
+}
+
+// GetMember 查询频道成员的资料，结果会缓存一段时间，参考 WithMemberProfileTTL
+func (c *Client) GetMember(openID string) (*MemberProfile, error) {
+	return c.GetMemberContext(context.Background(), openID)
+}
+
+// GetMemberContext 与 GetMember 相同，ctx 用于控制请求的取消和超时
+func (c *Client) GetMemberContext(ctx context.Context, openID string) (p *MemberProfile, err error) {
+	openID = strings.TrimSpace(openID)
+	if openID == "" {
+		return nil, ErrOpenIDRequired
+	}
+
+	if c.profiles != nil {
+		if v, ok := c.profiles.Get(openID); ok {
+			profile := v.(MemberProfile)
+			return &profile, nil
+		}
+	}
+
+	ctx, span := c.trace().Start(ctx, "sm.GetMember", SpanKindInternal, nil)
+	defer func() { span.End(err) }()
+
+	req, err := http.NewRequest("GET", c.apiURL("/members/profile")+"?openID="+url.QueryEscape(openID), nil)
+	if err != nil {
+		return
+	}
+
+	p = &MemberProfile{}
+	if err = c.doRequest(req.WithContext(ctx), "/members/profile", p); err != nil {
+		return nil, err
+	}
+
+	if c.profiles != nil {
+		c.profiles.SetDefault(openID, *p)
+	}
+	return
+}
+
+// CountMembers 返回频道的订阅人数
+func (c *Client) CountMembers() (int, error) {
+	return c.CountMembersContext(context.Background())
+}
+
+// CountMembersContext 与 CountMembers 相同，ctx 用于控制请求的取消和超时
+func (c *Client) CountMembersContext(ctx context.Context) (n int, err error) {
+	ctx, span := c.trace().Start(ctx, "sm.CountMembers", SpanKindInternal, nil)
+	defer func() { span.End(err) }()
+
+	req, err := http.NewRequest("GET", c.apiURL("/members/count"), nil)
+	if err != nil {
+		return
+	}
+
+	expected := &struct {
+		Count int `json:"count"`
+	}{}
+	if err = c.doRequest(req.WithContext(ctx), "/members/count", expected); err != nil {
+		return
+	}
+	return expected.Count, nil
+}
+
+// MemberFilter 为查询成员列表的条件，零值表示不限
+type MemberFilter struct {
+	// 订阅时间在 [Since, Until) 范围内的成员
+	Since time.Time
+	Until time.Time
+
+	// 每页的成员数量，为 0 时使用平台的默认值
+	Limit int
+	// 上一页返回的 NextCursor，为空时从第一页开始
+	Cursor string
+}
+
+func (f *MemberFilter) query() url.Values {
+	query := url.Values{}
+	if !f.Since.IsZero() {
+		query.Set("since", strconv.FormatInt(f.Since.Unix(), 10))
+	}
+	if !f.Until.IsZero() {
+		query.Set("until", strconv.FormatInt(f.Until.Unix(), 10))
+	}
+	if f.Limit > 0 {
+		query.Set("limit", strconv.Itoa(f.Limit))
+	}
+	if f.Cursor != "" {
+		query.Set("cursor", f.Cursor)
+	}
+	return query
+}
+
+// MemberPage 为成员列表的一页，按订阅时间倒序排列，NextCursor 为空表示没有下一页
+type MemberPage struct {
+	Members    []*MemberProfile `json:"members"`
+	NextCursor string           `json:"nextCursor"`
+}
+
+// ListMembers 查询频道的订阅成员，每次返回一页，遍历所有成员可以使用 IterateMembers。
+// 返回的成员资料同时会更新 GetMember 的缓存
+func (c *Client) ListMembers(filter *MemberFilter) (*MemberPage, error) {
+	return c.ListMembersContext(context.Background(), filter)
+}
+
+// ListMembersContext 与 ListMembers 相同，ctx 用于控制请求的取消和超时
+func (c *Client) ListMembersContext(ctx context.Context, filter *MemberFilter) (page *MemberPage, err error) {
+	if filter == nil {
+		filter = &MemberFilter{}
+	}
+
+	ctx, span := c.trace().Start(ctx, "sm.ListMembers", SpanKindInternal, nil)
+	defer func() {
+		if page != nil {
+			span.SetAttributes(map[string]interface{}{AttrRecipientCount: len(page.Members)})
+		}
+		span.End(err)
+	}()
+
+	req, err := http.NewRequest("GET", c.apiURL("/members")+"?"+filter.query().Encode(), nil)
+	if err != nil {
+		return
+	}
+
+	page = &MemberPage{}
+	if err = c.doRequest(req.WithContext(ctx), "/members", page); err != nil {
+		return nil, err
+	}
+
+	if c.profiles != nil {
+		for _, p := range page.Members {
+			c.profiles.SetDefault(p.OpenID, *p)
+		}
+	}
+	return
+}
+
+// MemberIterator 逐个遍历频道成员，按需请求下一页，用法与 MessageIterator 相同：
+//      it := client.IterateMembers(ctx, MemberFilter{})
+//      for it.Next() {
+//          p := it.Member()
+//      }
+//      if err := it.Err(); err != nil {
+//          ...
+//      }
+type MemberIterator struct {
+	ctx    context.Context
+	client *Client
+	filter MemberFilter
+
+	members []*MemberProfile
+	current *MemberProfile
+	done    bool
+	err     error
+}
+
+// IterateMembers 返回遍历符合条件的所有成员的迭代器，filter.Cursor 不为空时从该位置开始
+func (c *Client) IterateMembers(ctx context.Context, filter MemberFilter) *MemberIterator {
+	return &MemberIterator{ctx: ctx, client: c, filter: filter}
+}
+
+// Next 移动到下一个成员，没有更多成员或者出错时返回 false
+func (it *MemberIterator) Next() bool {
+	for len(it.members) == 0 {
+		if it.done || it.err != nil {
+			return false
+		}
+
+		page, err := it.client.ListMembersContext(it.ctx, &it.filter)
+		if err != nil {
+			it.err = err
+			return false
+		}
+
+		it.members = page.Members
+		it.filter.Cursor = page.NextCursor
+		it.done = page.NextCursor == ""
+	}
+
+	it.current, it.members = it.members[0], it.members[1:]
+	return true
+}
+
+// Member 返回当前的成员
+func (it *MemberIterator) Member() *MemberProfile {
+	return it.current
+}
+
+// Err 返回遍历过程中发生的错误
+func (it *MemberIterator) Err() error {
+	return it.err
+}
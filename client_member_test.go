@@ -0,0 +1,59 @@
+package go_sdk
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestMembers(t *testing.T) {
+	profileRequests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var data interface{}
+		switch r.URL.Path {
+		case "/v1/members/profile":
+			profileRequests++
+			data = MemberProfile{OpenID: r.URL.Query().Get("openID"), Nickname: "nick"}
+		case "/v1/members/count":
+			data = map[string]int{"count": 3}
+		case "/v1/members":
+			if r.URL.Query().Get("cursor") == "" {
+				data = MemberPage{Members: []*MemberProfile{{OpenID: "u1"}, {OpenID: "u2"}}, NextCursor: "p2"}
+			} else {
+				data = MemberPage{Members: []*MemberProfile{{OpenID: "u3", Nickname: "listed"}}}
+			}
+		}
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"code": 0, "data": data})
+	}))
+	defer server.Close()
+
+	Convey("Member profiles are cached", t, func() {
+		client := NewClient("token", nil, WithAPIHost(server.URL))
+
+		p, err := client.GetMember("u1")
+		So(err, ShouldBeNil)
+		So(p.Nickname, ShouldEqual, "nick")
+		_, _ = client.GetMember("u1")
+		So(profileRequests, ShouldEqual, 1)
+
+		n, err := client.CountMembers()
+		So(err, ShouldBeNil)
+		So(n, ShouldEqual, 3)
+
+		var ids []string
+		it := client.IterateMembers(context.Background(), MemberFilter{Limit: 2})
+		for it.Next() {
+			ids = append(ids, it.Member().OpenID)
+		}
+		So(it.Err(), ShouldBeNil)
+		So(ids, ShouldResemble, []string{"u1", "u2", "u3"})
+
+		p, _ = client.GetMember("u3")
+		So(p.Nickname, ShouldEqual, "listed")
+		So(profileRequests, ShouldEqual, 1)
+	})
+}
@@ -0,0 +1,167 @@
+package go_sdk
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// GetMessage 查询一条已推送的消息，包括接收人、模板、标题、数据以及创建和修改时间
+func (c *Client) GetMessage(messageID int64) (*Message, error) {
+	return c.GetMessageContext(context.Background(), messageID)
+}
+
+// GetMessageContext 与 GetMessage 相同，ctx 用于控制请求的取消和超时
+func (c *Client) GetMessageContext(ctx context.Context, messageID int64) (m *Message, err error) {
+	ctx, span := c.trace().Start(ctx, "sm.GetMessage", SpanKindInternal, map[string]interface{}{AttrMessageID: messageID})
+	defer func() { span.End(err) }()
+
+	if messageID <= 0 {
+		return nil, ErrMessageIDRequired
+	}
+
+	req, err := http.NewRequest("GET", c.apiURL("/messages")+"?id="+strconv.FormatInt(messageID, 10), nil)
+	if err != nil {
+		return
+	}
+
+	m = &Message{}
+	if err = c.doRequest(req.WithContext(ctx), "/messages", m); err != nil {
+		return nil, err
+	}
+	return
+}
+
+// MessageFilter 为查询消息列表的条件，零值表示不限
+type MessageFilter struct {
+	// 使用指定模板的消息
+	TemplateID string
+	// 发给指定成员的消息，包括发给全体成员的消息
+	Recipient string
+	// 创建时间在 [Since, Until) 范围内的消息
+	Since time.Time
+	Until time.Time
+
+	// 每页的消息数量，为 0 时使用平台的默认值
+	Limit int
+	// 上一页返回的 NextCursor，为空时从第一页开始
+	Cursor string
+}
+
+func (f *MessageFilter) query() url.Values {
+	query := url.Values{}
+	if f.TemplateID != "" {
+		query.Set("templateID", f.TemplateID)
+	}
+	if f.Recipient != "" {
+		query.Set("recipient", f.Recipient)
+	}
+	if !f.Since.IsZero() {
+		query.Set("since", strconv.FormatInt(f.Since.Unix(), 10))
+	}
+	if !f.Until.IsZero() {
+		query.Set("until", strconv.FormatInt(f.Until.Unix(), 10))
+	}
+	if f.Limit > 0 {
+		query.Set("limit", strconv.Itoa(f.Limit))
+	}
+	if f.Cursor != "" {
+		query.Set("cursor", f.Cursor)
+	}
+	return query
+}
+
+// MessagePage 为消息列表的一页，按创建时间倒序排列，NextCursor 为空表示没有下一页
+type MessagePage struct {
+	Messages   []*Message `json:"messages"`
+	NextCursor string     `json:"nextCursor"`
+}
+
+// ListMessages 按条件查询已推送的消息，每次返回一页，遍历所有消息可以使用 IterateMessages
+func (c *Client) ListMessages(filter *MessageFilter) (*MessagePage, error) {
+	return c.ListMessagesContext(context.Background(), filter)
+}
+
+// ListMessagesContext 与 ListMessages 相同，ctx 用于控制请求的取消和超时
+func (c *Client) ListMessagesContext(ctx context.Context, filter *MessageFilter) (page *MessagePage, err error) {
+	if filter == nil {
+		filter = &MessageFilter{}
+	}
+
+	ctx, span := c.trace().Start(ctx, "sm.ListMessages", SpanKindInternal, map[string]interface{}{AttrTemplateID: filter.TemplateID})
+	defer func() {
+		if page != nil {
+			span.SetAttributes(map[string]interface{}{AttrMessageCount: len(page.Messages)})
+		}
+		span.End(err)
+	}()
+
+	req, err := http.NewRequest("GET", c.apiURL("/messages/list")+"?"+filter.query().Encode(), nil)
+	if err != nil {
+		return
+	}
+
+	page = &MessagePage{}
+	if err = c.doRequest(req.WithContext(ctx), "/messages/list", page); err != nil {
+		return nil, err
+	}
+	return
+}
+
+// MessageIterator 逐条遍历消息列表，按需请求下一页，用法与 bufio.Scanner 类似：
+//      it := client.IterateMessages(ctx, MessageFilter{TemplateID: "..."})
+//      for it.Next() {
+//          m := it.Message()
+//      }
+//      if err := it.Err(); err != nil {
+//          ...
+//      }
+type MessageIterator struct {
+	ctx    context.Context
+	client *Client
+	filter MessageFilter
+
+	messages []*Message
+	current  *Message
+	done     bool
+	err      error
+}
+
+// IterateMessages 返回遍历符合条件的所有消息的迭代器，filter.Cursor 不为空时从该位置开始
+func (c *Client) IterateMessages(ctx context.Context, filter MessageFilter) *MessageIterator {
+	return &MessageIterator{ctx: ctx, client: c, filter: filter}
+}
+
+// Next 移动到下一条消息，没有更多消息或者出错时返回 false
+func (it *MessageIterator) Next() bool {
+	for len(it.messages) == 0 {
+		if it.done || it.err != nil {
+			return false
+		}
+
+		page, err := it.client.ListMessagesContext(it.ctx, &it.filter)
+		if err != nil {
+			it.err = err
+			return false
+		}
+
+		it.messages = page.Messages
+		it.filter.Cursor = page.NextCursor
+		it.done = page.NextCursor == ""
+	}
+
+	it.current, it.messages = it.messages[0], it.messages[1:]
+	return true
+}
+
+// Message 返回当前的消息
+func (it *MessageIterator) Message() *Message {
+	return it.current
+}
+
+// Err 返回遍历过程中发生的错误
+func (it *MessageIterator) Err() error {
+	return it.err
+}
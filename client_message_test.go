@@ -0,0 +1,85 @@
+package go_sdk
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestListMessages(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		var data interface{}
+		switch r.URL.Path {
+		case "/v1/messages":
+			data = Message{ID: 1, Title: "title", CreatedAt: 100}
+		case "/v1/messages/list":
+			if query.Get("templateID") != "t1" || query.Get("since") != "100" {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			if query.Get("cursor") == "" {
+				data = MessagePage{Messages: []*Message{{ID: 3}, {ID: 2}}, NextCursor: "p2"}
+			} else {
+				data = MessagePage{Messages: []*Message{{ID: 1}}}
+			}
+		}
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"code": 0, "data": data})
+	}))
+	defer server.Close()
+
+	client := NewClient("token", nil, WithAPIHost(server.URL))
+
+	Convey("GetMessage returns the message", t, func() {
+		m, err := client.GetMessage(1)
+		So(err, ShouldBeNil)
+		So(m.Title, ShouldEqual, "title")
+		So(m.CreatedAt, ShouldEqual, 100)
+	})
+
+	Convey("IterateMessages walks through all pages", t, func() {
+		it := client.IterateMessages(context.Background(), MessageFilter{TemplateID: "t1", Since: time.Unix(100, 0)})
+
+		var ids []int64
+		for it.Next() {
+			ids = append(ids, it.Message().ID)
+		}
+		So(it.Err(), ShouldBeNil)
+		So(ids, ShouldResemble, []int64{3, 2, 1})
+
+		it = client.IterateMessages(context.Background(), MessageFilter{})
+		So(it.Next(), ShouldBeFalse)
+		So(it.Err(), ShouldResemble, &StatusError{StatusCode: http.StatusBadRequest})
+	})
+}
+
+func TestPatchMessage(t *testing.T) {
+	var method string
+	var body map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		method = r.Method
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"code": 0})
+	}))
+	defer server.Close()
+
+	client := NewClient("token", nil, WithAPIHost(server.URL))
+
+	Convey("PatchMessage sends the operations of the update part", t, func() {
+		So(client.PatchMessage(1, NewUpdatePart()), ShouldEqual, ErrEmptyUpdatePart)
+
+		ops := NewUpdatePart()
+		ops.AddOpSet(NewSet().Add("order.status", "shipped"))
+		So(client.PatchMessage(1, ops), ShouldBeNil)
+		So(method, ShouldEqual, "PATCH")
+		So(body["id"], ShouldEqual, 1)
+		So(body["ops"], ShouldResemble, []interface{}{
+			map[string]interface{}{"$set": map[string]interface{}{"order.status": "shipped"}},
+		})
+	})
+}
@@ -1,7 +1,12 @@
 package go_sdk
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"io"
+	"net"
+	"net/http"
 )
 
 // APIError 定义平台接口统一的返回错误信息的结构
@@ -19,6 +24,42 @@ func (e *APIError) IsInvalidRequestToken() bool {
 	return e.Code == 10001
 }
 
+// StatusError 表示平台接口返回了非 200 的 HTTP 状态码，通常是网络或服务器故障
+type StatusError struct {
+	StatusCode int
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("the server api responses an unexpected status code, expect 200, but got %d", e.StatusCode)
+}
+
+// isTemporaryError 返回错误是否为临时性的，临时性的错误稍后重试可能成功，包括网络问题、超时、服务器故障（5xx）、
+// 限流（429、ErrRateLimited）以及熔断（ErrCircuitOpen）。其它错误，比如业务性的错误（APIError）、
+// 请求数据不合法、没有 access token 等，重试也不会成功
+func isTemporaryError(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	if se, ok := err.(*StatusError); ok {
+		return se.StatusCode >= 500 || se.StatusCode == http.StatusTooManyRequests || se.StatusCode == http.StatusRequestTimeout
+	}
+
+	switch {
+	case errors.Is(err, context.Canceled):
+		// 调用方取消了请求
+		return false
+	case errors.Is(err, ErrRateLimited), errors.Is(err, ErrCircuitOpen), errors.Is(err, context.DeadlineExceeded):
+		return true
+	case errors.Is(err, io.EOF), errors.Is(err, io.ErrUnexpectedEOF):
+		// 连接在读取响应的过程中被断开
+		return true
+	}
+
+	var ne net.Error
+	return errors.As(err, &ne)
+}
+
 type response struct {
 	*APIError
 	Data interface{} `json:"data,omitempty"`
@@ -50,4 +91,8 @@ type Message struct {
 
 	// 消息内容/状态，可以为空
 	Data map[string]interface{} `json:"data"`
+
+	// 消息创建和最后修改时间的 UNIX 时间戳，只在通过 GetMessage、ListMessages 查询时返回
+	CreatedAt int64 `json:"createdAt,omitempty"`
+	UpdatedAt int64 `json:"updatedAt,omitempty"`
 }
@@ -0,0 +1,136 @@
+package go_sdk
+
+import (
+	"errors"
+	"net/http"
+	"sync"
+)
+
+var ErrChannelNotFound = errors.New("channel not found")
+
+// ChannelLoader 返回频道对应的 access token，频道不存在时返回 ErrChannelNotFound
+type ChannelLoader func(channelID string) (accessToken string, err error)
+
+// ClientSet 管理多个频道的 Client，根据 App 请求中的频道 ID（_cid）选择对应的 Client。
+// 所有 Client 共用同一个 RequestTokenCache（按频道 ID 区分缓存的 key）以及 NewClientSet 传入的其它参数，
+// 比如通过 WithHTTPClient 共享连接池
+type ClientSet struct {
+	mutex   sync.RWMutex
+	clients map[string]*Client
+	loader  ChannelLoader
+	cache   RequestTokenCache
+	opts    []ClientOption
+}
+
+// NewClientSet 新建一个 ClientSet，tokens 为频道 ID 到 access token 的映射，可以来自配置文件：
+//      clients := NewClientSet(map[string]string{"channel1": "token1", "channel2": "token2"}, NewMemoryCache())
+// 之后可以通过 Add、Remove 增减频道
+func NewClientSet(tokens map[string]string, cache RequestTokenCache, opts ...ClientOption) *ClientSet {
+	s := &ClientSet{
+		clients: make(map[string]*Client, len(tokens)),
+		cache:   cache,
+		opts:    opts,
+	}
+
+	for channelID, accessToken := range tokens {
+		s.clients[channelID] = s.newClient(channelID, accessToken)
+	}
+	return s
+}
+
+// NewClientSetWithLoader 新建一个 ClientSet，首次访问某个频道时调用 loader 获取 access token，
+// 适用于频道较多、保存在数据库中的情况
+func NewClientSetWithLoader(loader ChannelLoader, cache RequestTokenCache, opts ...ClientOption) *ClientSet {
+	s := NewClientSet(nil, cache, opts...)
+	s.loader = loader
+	return s
+}
+
+func (s *ClientSet) newClient(channelID, accessToken string) *Client {
+	var cache RequestTokenCache
+	if s.cache != nil {
+		cache = &channelCache{prefix: channelID + ":", cache: s.cache}
+	}
+	return NewClient(accessToken, cache, s.opts...)
+}
+
+// Add 添加或替换频道的 Client
+func (s *ClientSet) Add(channelID, accessToken string) *Client {
+	c := s.newClient(channelID, accessToken)
+
+	s.mutex.Lock()
+	s.clients[channelID] = c
+	s.mutex.Unlock()
+	return c
+}
+
+// Remove 移除频道的 Client，设置了 loader 时下次访问该频道会重新加载
+func (s *ClientSet) Remove(channelID string) {
+	s.mutex.Lock()
+	delete(s.clients, channelID)
+	s.mutex.Unlock()
+}
+
+// Client 返回频道对应的 Client
+func (s *ClientSet) Client(channelID string) (*Client, error) {
+	s.mutex.RLock()
+	c, ok := s.clients[channelID]
+	s.mutex.RUnlock()
+	if ok {
+		return c, nil
+	}
+
+	if s.loader == nil || channelID == "" {
+		return nil, ErrChannelNotFound
+	}
+
+	accessToken, err := s.loader(channelID)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	// 并发加载同一个频道时使用先保存的 Client
+	if c, ok = s.clients[channelID]; !ok {
+		c = s.newClient(channelID, accessToken)
+		s.clients[channelID] = c
+	}
+	return c, nil
+}
+
+// VerifyRequest 解析 App 请求的参数，使用请求所在频道的 Client 验证 request token，
+// 返回的 Client 可以用于处理函数中推送、修改该频道的消息
+func (s *ClientSet) VerifyRequest(r *http.Request) (q *QueryParameter, m Member, c *Client, err error) {
+	q, err = QueryParameterFrom(r)
+	if err != nil {
+		return
+	}
+
+	c, err = s.Client(q.ChannelID)
+	if err != nil {
+		return
+	}
+
+	m, err = c.VerifyRequestTokenContext(r.Context(), q.RequestToken)
+	return
+}
+
+// channelCache 为共享的 RequestTokenCache 的 key 加上频道前缀，不同频道的 request token 互不影响
+type channelCache struct {
+	prefix string
+	cache  RequestTokenCache
+}
+
+func (c *channelCache) Get(rt string) (Member, bool) {
+	return c.cache.Get(c.prefix + rt)
+}
+
+func (c *channelCache) Set(rt string, member Member) error {
+	return c.cache.Set(c.prefix+rt, member)
+}
+
+func (c *channelCache) Delete(rt string) {
+	c.cache.Delete(c.prefix + rt)
+}
@@ -0,0 +1,59 @@
+package go_sdk
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestClientSet(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// 以 access token 作为成员的 open id，便于确认使用了哪个频道的 Client
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"code": 0,
+			"data": Member{OpenID: r.URL.Query().Get("accessToken"), ExpiredAt: time.Now().Unix() + 60},
+		})
+	}))
+	defer server.Close()
+
+	Convey("Requests are verified by the client of their channel", t, func() {
+		cache := NewMemoryCache()
+		loads := 0
+		clients := NewClientSetWithLoader(func(channelID string) (string, error) {
+			loads++
+			if channelID == "c2" {
+				return "token2", nil
+			}
+			return "", ErrChannelNotFound
+		}, cache, WithAPIHost(server.URL))
+		clients.Add("c1", "token1")
+
+		r := httptest.NewRequest("GET", "/todos?_rt=rt&_cid=c1", nil)
+		_, m, c, err := clients.VerifyRequest(r)
+		So(err, ShouldBeNil)
+		So(m.OpenID, ShouldEqual, "token1")
+		token, _ := c.tokenSource.Token()
+		So(token, ShouldEqual, "token1")
+
+		r = httptest.NewRequest("GET", "/todos?_rt=rt&_cid=c2", nil)
+		_, m, _, err = clients.VerifyRequest(r)
+		So(err, ShouldBeNil)
+		So(m.OpenID, ShouldEqual, "token2")
+
+		_, _, _, err = clients.VerifyRequest(r)
+		So(err, ShouldBeNil)
+		So(loads, ShouldEqual, 1)
+
+		cached, ok := cache.Get("c1:rt")
+		So(ok, ShouldBeTrue)
+		So(cached.OpenID, ShouldEqual, "token1")
+
+		r = httptest.NewRequest("GET", "/todos?_rt=rt&_cid=c3", nil)
+		_, _, _, err = clients.VerifyRequest(r)
+		So(err, ShouldEqual, ErrChannelNotFound)
+	})
+}
@@ -0,0 +1,190 @@
+package go_sdk
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// TemplateVersionInfo 为平台上模板的一个已发布版本
+type TemplateVersionInfo struct {
+	Version int `json:"version"`
+	// 发布时间的 UNIX 时间戳
+	CreatedAt int64 `json:"createdAt"`
+}
+
+type templateResponse struct {
+	ID      string `json:"id"`
+	Version int    `json:"version"`
+}
+
+func (c *Client) postTemplate(ctx context.Context, path string, t *Template) (*templateResponse, error) {
+	body := new(bytes.Buffer)
+	if err := json.NewEncoder(body).Encode(t); err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest("POST", c.apiURL(path), body)
+	if err != nil {
+		return nil, err
+	}
+
+	expected := &templateResponse{}
+	if err = c.doRequest(req.WithContext(ctx), path, expected); err != nil {
+		return nil, err
+	}
+	return expected, nil
+}
+
+// CreateTemplate 在平台上创建模板，成功后将平台分配的 ID 和版本号写入 t
+func (c *Client) CreateTemplate(t *Template) error {
+	return c.CreateTemplateContext(context.Background(), t)
+}
+
+// CreateTemplateContext 与 CreateTemplate 相同，ctx 用于控制请求的取消和超时
+func (c *Client) CreateTemplateContext(ctx context.Context, t *Template) (err error) {
+	ctx, span := c.trace().Start(ctx, "sm.CreateTemplate", SpanKindInternal, nil)
+	defer func() { span.End(err) }()
+
+	if strings.TrimSpace(t.Name) == "" {
+		return ErrTemplateNameRequired
+	}
+
+	resp, err := c.postTemplate(ctx, "/templates", t)
+	if err != nil {
+		return
+	}
+
+	t.ID, t.Version = resp.ID, resp.Version
+	span.SetAttributes(map[string]interface{}{AttrTemplateID: t.ID, AttrTemplateVersion: t.Version})
+	return
+}
+
+// PublishTemplateVersion 将 t 的源码和数据结构发布为模板 t.ID 的新版本，成功后将新的版本号写入 t
+func (c *Client) PublishTemplateVersion(t *Template) error {
+	return c.PublishTemplateVersionContext(context.Background(), t)
+}
+
+// PublishTemplateVersionContext 与 PublishTemplateVersion 相同，ctx 用于控制请求的取消和超时
+func (c *Client) PublishTemplateVersionContext(ctx context.Context, t *Template) (err error) {
+	ctx, span := c.trace().Start(ctx, "sm.PublishTemplateVersion", SpanKindInternal, map[string]interface{}{AttrTemplateID: t.ID})
+	defer func() { span.End(err) }()
+
+	if t.ID == "" {
+		return ErrTemplateIDRequired
+	}
+
+	resp, err := c.postTemplate(ctx, "/templates/versions", t)
+	if err != nil {
+		return
+	}
+
+	t.Version = resp.Version
+	span.SetAttributes(map[string]interface{}{AttrTemplateVersion: t.Version})
+	return
+}
+
+// ListTemplateVersions 返回模板已发布的所有版本，按版本号升序排列
+func (c *Client) ListTemplateVersions(templateID string) ([]TemplateVersionInfo, error) {
+	return c.ListTemplateVersionsContext(context.Background(), templateID)
+}
+
+// ListTemplateVersionsContext 与 ListTemplateVersions 相同，ctx 用于控制请求的取消和超时
+func (c *Client) ListTemplateVersionsContext(ctx context.Context, templateID string) (versions []TemplateVersionInfo, err error) {
+	ctx, span := c.trace().Start(ctx, "sm.ListTemplateVersions", SpanKindInternal, map[string]interface{}{AttrTemplateID: templateID})
+	defer func() { span.End(err) }()
+
+	if templateID == "" {
+		return nil, ErrTemplateIDRequired
+	}
+
+	req, err := http.NewRequest("GET", c.apiURL("/templates/versions")+"?id="+url.QueryEscape(templateID), nil)
+	if err != nil {
+		return
+	}
+
+	err = c.doRequest(req.WithContext(ctx), "/templates/versions", &versions)
+	return
+}
+
+// GetTemplate 获取模板指定版本的源码和数据结构，version 为 0 时获取最新版本。
+// 返回的模板没有 Name，Name 只在本地的模板文件中使用
+func (c *Client) GetTemplate(templateID string, version int) (*Template, error) {
+	return c.GetTemplateContext(context.Background(), templateID, version)
+}
+
+// GetTemplateContext 与 GetTemplate 相同，ctx 用于控制请求的取消和超时
+func (c *Client) GetTemplateContext(ctx context.Context, templateID string, version int) (t *Template, err error) {
+	ctx, span := c.trace().Start(ctx, "sm.GetTemplate", SpanKindInternal, map[string]interface{}{
+		AttrTemplateID:      templateID,
+		AttrTemplateVersion: version,
+	})
+	defer func() { span.End(err) }()
+
+	if templateID == "" {
+		return nil, ErrTemplateIDRequired
+	}
+
+	query := url.Values{"id": {templateID}}
+	if version > 0 {
+		query.Set("version", strconv.Itoa(version))
+	}
+
+	req, err := http.NewRequest("GET", c.apiURL("/templates")+"?"+query.Encode(), nil)
+	if err != nil {
+		return
+	}
+
+	t = &Template{}
+	if err = c.doRequest(req.WithContext(ctx), "/templates", t); err != nil {
+		return nil, err
+	}
+	return
+}
+
+// UploadTemplate 将本地的模板文件同步到平台，用于在 CI 中发布模板：
+//      1. 模板没有 ID 时创建模板
+//      2. 模板的源码或数据结构与平台上的最新版本不同时发布新版本
+//      3. 否则不做修改，只将 t.Version 更新为平台上的最新版本
+// 返回 t 的 ID 或版本号是否有变化，有变化时通常需要调用 SaveTemplateFile 将其写回模板文件，
+// 部署的服务通过 TemplateRegistry 读取模板文件即可使用新的版本
+func (c *Client) UploadTemplate(t *Template) (changed bool, err error) {
+	return c.UploadTemplateContext(context.Background(), t)
+}
+
+// UploadTemplateContext 与 UploadTemplate 相同，ctx 用于控制请求的取消和超时
+func (c *Client) UploadTemplateContext(ctx context.Context, t *Template) (changed bool, err error) {
+	if t.ID == "" {
+		return true, c.CreateTemplateContext(ctx, t)
+	}
+
+	latest, err := c.GetTemplateContext(ctx, t.ID, 0)
+	if err != nil {
+		return
+	}
+
+	if latest.Source == t.Source && sameTemplateData(latest.Data, t.Data) {
+		changed = latest.Version != t.Version
+		t.Version = latest.Version
+		return
+	}
+
+	return true, c.PublishTemplateVersionContext(ctx, t)
+}
+
+// sameTemplateData 比较模板的数据结构，nil 与空对象视为相同
+func sameTemplateData(a, b map[string]interface{}) bool {
+	if len(a) == 0 && len(b) == 0 {
+		return true
+	}
+
+	// 统一转换为 JSON 解码后的形式再比较
+	na, errA := normalize(a)
+	nb, errB := normalize(b)
+	return errA == nil && errB == nil && reflect.DeepEqual(na, nb)
+}
@@ -0,0 +1,68 @@
+package go_sdk
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestUploadTemplate(t *testing.T) {
+	// 模拟平台保存的模板各版本
+	var versions []Template
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var data interface{}
+		switch {
+		case r.Method == "POST":
+			tpl := Template{}
+			_ = json.NewDecoder(r.Body).Decode(&tpl)
+			tpl.ID, tpl.Version = "t1", len(versions)+1
+			versions = append(versions, tpl)
+			data = map[string]interface{}{"id": tpl.ID, "version": tpl.Version}
+		case r.URL.Path == "/v1/templates":
+			data = versions[len(versions)-1]
+		}
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"code": 0, "data": data})
+	}))
+	defer server.Close()
+
+	Convey("UploadTemplate creates templates and publishes changed versions only", t, func() {
+		client := NewClient("token", nil, WithAPIHost(server.URL))
+		tpl := &Template{Name: "todo", Source: `<Text>{{title}}</Text>`, Data: map[string]interface{}{"title": "string"}}
+
+		changed, err := client.UploadTemplate(tpl)
+		So(err, ShouldBeNil)
+		So(changed, ShouldBeTrue)
+		So(tpl.ID, ShouldEqual, "t1")
+		So(tpl.Version, ShouldEqual, 1)
+
+		changed, err = client.UploadTemplate(tpl)
+		So(err, ShouldBeNil)
+		So(changed, ShouldBeFalse)
+		So(len(versions), ShouldEqual, 1)
+
+		tpl.Source = `<Text>{{title}}!</Text>`
+		changed, err = client.UploadTemplate(tpl)
+		So(err, ShouldBeNil)
+		So(changed, ShouldBeTrue)
+		So(tpl.Version, ShouldEqual, 2)
+
+		dir, _ := ioutil.TempDir("", "sm-template")
+		defer os.RemoveAll(dir)
+
+		filename := filepath.Join(dir, "todo.json")
+		So(SaveTemplateFile(filename, tpl), ShouldBeNil)
+		b, _ := ioutil.ReadFile(filename)
+		So(strings.Contains(string(b), `<Text>`), ShouldBeTrue)
+
+		saved, err := LoadTemplateFile(filename)
+		So(err, ShouldBeNil)
+		So(saved, ShouldResemble, tpl)
+	})
+}
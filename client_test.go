@@ -1,6 +1,15 @@
 package go_sdk
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -32,3 +41,69 @@ func TestMemoryCache(t *testing.T) {
 		})
 	})
 }
+
+func TestVerifyRequestTokenRevalidate(t *testing.T) {
+	var calls int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt64(&calls, 1)
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"code": 0,
+			"data": Member{OpenID: "u1", ExpiredAt: time.Now().Unix() + 3600},
+		})
+	}))
+	defer server.Close()
+
+	originHost := apiHost
+	apiHost = server.URL
+	defer func() { apiHost = originHost }()
+
+	Convey("Entries near expiry are served and refreshed in background", t, func() {
+		cache := NewMemoryCache()
+		_ = cache.Set("rt", Member{OpenID: "u1", ExpiredAt: time.Now().Unix() + 30})
+		client := NewClient("token", cache, WithStaleWhileRevalidate(time.Minute))
+
+		m, err := client.VerifyRequestToken("rt")
+		So(err, ShouldBeNil)
+		So(m.OpenID, ShouldEqual, "u1")
+
+		time.Sleep(100 * time.Millisecond)
+		So(atomic.LoadInt64(&calls), ShouldEqual, 1)
+
+		m, _ = cache.Get("rt")
+		So(m.ExpiredAt, ShouldBeGreaterThan, time.Now().Unix()+60)
+
+		_, _ = client.VerifyRequestToken("rt")
+		time.Sleep(50 * time.Millisecond)
+		So(atomic.LoadInt64(&calls), ShouldEqual, 1)
+	})
+}
+
+func TestIsTemporaryError(t *testing.T) {
+	Convey("Only known transient failures are retried", t, func() {
+		_, jsonErr := json.Marshal(make(chan int))
+		cases := []struct {
+			err       error
+			temporary bool
+		}{
+			{&StatusError{StatusCode: http.StatusBadGateway}, true},
+			{&StatusError{StatusCode: http.StatusTooManyRequests}, true},
+			{&StatusError{StatusCode: http.StatusNotFound}, false},
+			{&APIError{Code: 10001, Message: "invalid"}, false},
+			{ErrRateLimited, true},
+			{ErrCircuitOpen, true},
+			{&url.Error{Op: "Post", URL: "http://sm", Err: &net.OpError{Op: "dial", Err: errors.New("connection refused")}}, true},
+			{&url.Error{Op: "Post", URL: "http://sm", Err: context.DeadlineExceeded}, true},
+			{&url.Error{Op: "Post", URL: "http://sm", Err: context.Canceled}, false},
+			{io.ErrUnexpectedEOF, true},
+			{ErrAccessTokenRequired, false},
+			{ErrMessageTitleRequired, false},
+			{jsonErr, false},
+			{errors.New("unknown"), false},
+			{nil, false},
+		}
+
+		for _, c := range cases {
+			So(isTemporaryError(c.err), ShouldEqual, c.temporary)
+		}
+	})
+}
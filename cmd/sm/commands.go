@@ -0,0 +1,315 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+
+	go_sdk "github.com/super-message/go-sdk"
+)
+
+// contentFlags 为 send 和 update 共用的消息内容参数
+type contentFlags struct {
+	template string
+	version  int
+	title    string
+	data     string
+}
+
+func (f *contentFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&f.template, "template", "", "template name in the template directory, or template ID")
+	fs.IntVar(&f.version, "version", 0, "template version, defaults to the current version of a named template")
+	fs.StringVar(&f.title, "title", "", "message title")
+	fs.StringVar(&f.data, "data", "", "message data as JSON, @file.json to read from a file, @- to read from stdin")
+}
+
+// request 构建消息内容，-template 在模板目录中存在时按名称解析出模板 ID 和版本号，否则作为模板 ID 使用
+func (f *contentFlags) request(templates string) (mcr go_sdk.MessageContentRequest, err error) {
+	mcr.TemplateID = f.template
+	mcr.TemplateVersion = int32(f.version)
+	mcr.Title = f.title
+
+	if templates != "" && f.template != "" {
+		registry := go_sdk.NewTemplateRegistry()
+		if err = registry.LoadDir(templates); err != nil {
+			return
+		}
+
+		if len(registry.Versions(f.template)) > 0 {
+			var t *go_sdk.Template
+			if f.version > 0 {
+				t, err = registry.Version(f.template, f.version)
+			} else {
+				t, err = registry.Current(f.template)
+			}
+			if err != nil {
+				return
+			}
+
+			mcr.TemplateID = t.ID
+			mcr.TemplateVersion = int32(t.Version)
+		}
+	}
+
+	mcr.Data, err = readData(f.data)
+	return
+}
+
+func readData(arg string) (map[string]interface{}, error) {
+	if arg == "" {
+		return nil, nil
+	}
+
+	b := []byte(arg)
+	if strings.HasPrefix(arg, "@") {
+		var err error
+		if arg == "@-" {
+			b, err = ioutil.ReadAll(os.Stdin)
+		} else {
+			b, err = ioutil.ReadFile(arg[1:])
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// 使用 json.Number 保留数字原样，避免大整数变为浮点数
+	var data map[string]interface{}
+	decoder := json.NewDecoder(bytes.NewReader(b))
+	decoder.UseNumber()
+	if err := decoder.Decode(&data); err != nil {
+		return nil, fmt.Errorf("invalid data: %s", err)
+	}
+	return data, nil
+}
+
+func splitList(s string) []string {
+	var list []string
+	for _, v := range strings.Split(s, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			list = append(list, v)
+		}
+	}
+	return list
+}
+
+func runSend(args []string) (interface{}, error) {
+	fs, g := newFlagSet("send")
+	content := &contentFlags{}
+	content.register(fs)
+	to := fs.String("to", "", "comma separated open IDs of recipients")
+	all := fs.Bool("all", false, "send to all members of the channel")
+	if positional := parse(fs, args); len(positional) > 0 {
+		return nil, fmt.Errorf("unexpected argument %q", positional[0])
+	}
+
+	recipients := splitList(*to)
+	if len(recipients) == 0 && !*all {
+		return nil, errors.New("either -to or -all is required")
+	}
+
+	client, p, err := g.client()
+	if err != nil {
+		return nil, err
+	}
+
+	mcr, err := content.request(p.Templates)
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := client.CreateMessage(&go_sdk.CreateMessageRequest{
+		Recipients:            recipients,
+		ToAll:                 *all,
+		MessageContentRequest: mcr,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string]interface{}{"messageID": id}, nil
+}
+
+func runUpdate(args []string) (interface{}, error) {
+	fs, g := newFlagSet("update")
+	content := &contentFlags{}
+	content.register(fs)
+	id := fs.Int64("id", 0, "message ID")
+	if positional := parse(fs, args); len(positional) > 0 {
+		return nil, fmt.Errorf("unexpected argument %q", positional[0])
+	}
+	if *id == 0 {
+		return nil, errors.New("-id is required")
+	}
+
+	client, p, err := g.client()
+	if err != nil {
+		return nil, err
+	}
+
+	mcr, err := content.request(p.Templates)
+	if err != nil {
+		return nil, err
+	}
+
+	err = client.UpdateMessage(&go_sdk.UpdateMessageRequest{ID: *id, MessageContentRequest: mcr})
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string]interface{}{"messageID": *id}, nil
+}
+
+func runDelete(args []string) (interface{}, error) {
+	fs, g := newFlagSet("delete")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: sm delete [flags] <messageID>")
+		fs.PrintDefaults()
+	}
+	positional := parse(fs, args)
+
+	if len(positional) != 1 {
+		return nil, &usageError{fs: fs}
+	}
+
+	id, err := strconv.ParseInt(positional[0], 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid message id %q", positional[0])
+	}
+
+	client, _, err := g.client()
+	if err != nil {
+		return nil, err
+	}
+
+	if err = client.DeleteMessage(id); err != nil {
+		return nil, err
+	}
+
+	return map[string]interface{}{"messageID": id}, nil
+}
+
+func runVerify(args []string) (interface{}, error) {
+	fs, g := newFlagSet("verify")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: sm verify [flags] <requestToken>")
+		fs.PrintDefaults()
+	}
+	positional := parse(fs, args)
+
+	if len(positional) != 1 {
+		return nil, &usageError{fs: fs}
+	}
+
+	client, _, err := g.client()
+	if err != nil {
+		return nil, err
+	}
+
+	return client.VerifyRequestToken(positional[0])
+}
+
+func runProfile(args []string) (interface{}, error) {
+	fs, g := newFlagSet("profile")
+	token := fs.String("token", "", "access token of the channel (set)")
+	apiHost := fs.String("api", "", "platform api host (set)")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: sm profile list | set <name> | use <name> | delete <name> [flags]")
+		fs.PrintDefaults()
+	}
+
+	if len(args) == 0 {
+		return nil, &usageError{fs: fs}
+	}
+	action := args[0]
+	positional := parse(fs, args[1:])
+
+	config, err := loadConfig(g.config)
+	if err != nil {
+		return nil, err
+	}
+
+	if action == "list" {
+		return listProfiles(config), nil
+	}
+
+	if len(positional) != 1 {
+		return nil, &usageError{fs: fs}
+	}
+	name := positional[0]
+
+	switch action {
+	case "set":
+		p, ok := config.Profiles[name]
+		if !ok {
+			p = &Profile{}
+			config.Profiles[name] = p
+		}
+		if *token != "" {
+			p.AccessToken = *token
+		}
+		if *apiHost != "" {
+			p.APIHost = *apiHost
+		}
+		if g.templates != "" {
+			p.Templates = g.templates
+		}
+		if p.AccessToken == "" {
+			return nil, errors.New("-token is required for a new profile")
+		}
+		if config.Current == "" {
+			config.Current = name
+		}
+	case "use":
+		if _, ok := config.Profiles[name]; !ok {
+			return nil, fmt.Errorf("profile %q not found", name)
+		}
+		config.Current = name
+	case "delete":
+		delete(config.Profiles, name)
+		if config.Current == name {
+			config.Current = ""
+		}
+	default:
+		return nil, &usageError{fs: fs}
+	}
+
+	if err = config.save(g.config); err != nil {
+		return nil, err
+	}
+	return listProfiles(config), nil
+}
+
+type profileOutput struct {
+	Name        string `json:"name"`
+	Current     bool   `json:"current"`
+	AccessToken string `json:"accessToken"`
+	APIHost     string `json:"apiHost,omitempty"`
+	Templates   string `json:"templates,omitempty"`
+}
+
+// listProfiles 列出所有 profile，access token 只显示前 4 位
+func listProfiles(config *Config) []profileOutput {
+	list := make([]profileOutput, 0, len(config.Profiles))
+	for _, name := range config.names() {
+		p := config.Profiles[name]
+		token := "****"
+		if len(p.AccessToken) > 4 {
+			token = p.AccessToken[:4] + "****"
+		}
+		list = append(list, profileOutput{
+			Name:        name,
+			Current:     name == config.Current,
+			AccessToken: token,
+			APIHost:     p.APIHost,
+			Templates:   p.Templates,
+		})
+	}
+	return list
+}
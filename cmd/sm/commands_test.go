@@ -0,0 +1,153 @@
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestReadData(t *testing.T) {
+	Convey("Data is read from an argument or a file", t, func() {
+		data, err := readData("")
+		So(err, ShouldBeNil)
+		So(data, ShouldBeNil)
+
+		data, err = readData(`{"id": 9007199254740993, "title": "a"}`)
+		So(err, ShouldBeNil)
+		So(data["id"], ShouldEqual, json.Number("9007199254740993"))
+		So(data["title"], ShouldEqual, "a")
+
+		path := filepath.Join(t.TempDir(), "data.json")
+		So(ioutil.WriteFile(path, []byte(`{"done": true}`), 0600), ShouldBeNil)
+		data, err = readData("@" + path)
+		So(err, ShouldBeNil)
+		So(data, ShouldResemble, map[string]interface{}{"done": true})
+
+		_, err = readData("@" + filepath.Join(t.TempDir(), "missing.json"))
+		So(err, ShouldNotBeNil)
+
+		_, err = readData(`[1, 2]`)
+		So(err, ShouldNotBeNil)
+
+		_, err = readData(`{"a":`)
+		So(err, ShouldNotBeNil)
+	})
+}
+
+func TestConfig(t *testing.T) {
+	t.Setenv("SM_PROFILE", "")
+	t.Setenv("SM_ACCESS_TOKEN", "")
+
+	Convey("Config is saved and loaded", t, func() {
+		path := filepath.Join(t.TempDir(), "sm", "config.json")
+
+		c, err := loadConfig(path)
+		So(err, ShouldBeNil)
+		So(c.Profiles, ShouldBeEmpty)
+
+		c.Current = "prod"
+		c.Profiles["prod"] = &Profile{AccessToken: "prod-token", Templates: "./templates"}
+		c.Profiles["dev"] = &Profile{AccessToken: "dev-token", APIHost: "http://localhost:8080"}
+		So(c.save(path), ShouldBeNil)
+
+		loaded, err := loadConfig(path)
+		So(err, ShouldBeNil)
+		So(loaded, ShouldResemble, c)
+		So(loaded.names(), ShouldResemble, []string{"dev", "prod"})
+
+		So(ioutil.WriteFile(path, []byte("{"), 0600), ShouldBeNil)
+		_, err = loadConfig(path)
+		So(err, ShouldNotBeNil)
+	})
+
+	Convey("Profiles are resolved by name, environment and current profile", t, func() {
+		c := &Config{
+			Current: "prod",
+			Profiles: map[string]*Profile{
+				"prod": {AccessToken: "prod-token"},
+				"dev":  {AccessToken: "dev-token"},
+				"none": {},
+			},
+		}
+
+		p, err := c.profile("")
+		So(err, ShouldBeNil)
+		So(p.AccessToken, ShouldEqual, "prod-token")
+
+		p, err = c.profile("dev")
+		So(err, ShouldBeNil)
+		So(p.AccessToken, ShouldEqual, "dev-token")
+
+		_, err = c.profile("staging")
+		So(err, ShouldNotBeNil)
+
+		_, err = c.profile("none")
+		So(err, ShouldNotBeNil)
+
+		t.Setenv("SM_PROFILE", "dev")
+		p, err = c.profile("")
+		So(err, ShouldBeNil)
+		So(p.AccessToken, ShouldEqual, "dev-token")
+
+		p, err = c.profile("prod")
+		So(err, ShouldBeNil)
+		So(p.AccessToken, ShouldEqual, "prod-token")
+
+		t.Setenv("SM_ACCESS_TOKEN", "env-token")
+		p, err = c.profile("none")
+		So(err, ShouldBeNil)
+		So(p.AccessToken, ShouldEqual, "env-token")
+		So(c.Profiles["none"].AccessToken, ShouldBeEmpty)
+
+		t.Setenv("SM_PROFILE", "")
+		t.Setenv("SM_ACCESS_TOKEN", "")
+		_, err = (&Config{Profiles: map[string]*Profile{}}).profile("")
+		So(err, ShouldNotBeNil)
+	})
+}
+
+func TestParse(t *testing.T) {
+	Convey("Flags and positional arguments can be mixed", t, func() {
+		fs, g := newFlagSet("test")
+		token := fs.String("token", "", "")
+
+		positional := parse(fs, []string{"set", "prod", "-token", "xxx", "-profile", "dev", "extra"})
+		So(positional, ShouldResemble, []string{"set", "prod", "extra"})
+		So(*token, ShouldEqual, "xxx")
+		So(g.profile, ShouldEqual, "dev")
+	})
+
+	Convey("Send and update reject stray arguments", t, func() {
+		_, err := runSend([]string{"-all", "-title", "a", "b"})
+		So(err, ShouldNotBeNil)
+		So(err.Error(), ShouldEqual, `unexpected argument "b"`)
+
+		_, err = runUpdate([]string{"-id", "1", "-title", "new", "title"})
+		So(err, ShouldNotBeNil)
+		So(err.Error(), ShouldEqual, `unexpected argument "title"`)
+
+		_, err = runUpdate([]string{"-title", "new"})
+		So(err, ShouldNotBeNil)
+	})
+
+	Convey("Usage errors are returned instead of exiting", t, func() {
+		t.Setenv("SM_CONFIG", filepath.Join(t.TempDir(), "config.json"))
+
+		for _, run := range []func() (interface{}, error){
+			func() (interface{}, error) { return runDelete(nil) },
+			func() (interface{}, error) { return runDelete([]string{"1", "2"}) },
+			func() (interface{}, error) { return runVerify(nil) },
+			func() (interface{}, error) { return runProfile(nil) },
+			func() (interface{}, error) { return runProfile([]string{"use"}) },
+			func() (interface{}, error) { return runProfile([]string{"rename", "prod"}) },
+			func() (interface{}, error) { return runTemplate(nil) },
+			func() (interface{}, error) { return runTemplate([]string{"push"}) },
+		} {
+			_, err := run()
+			So(err, ShouldHaveSameTypeAs, &usageError{})
+		}
+	})
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// Config 为 sm 的配置文件，默认保存在 ~/.config/sm/config.json，
+// 每个 profile 对应一个 Channel 的 access token，可以通过 --profile 切换
+type Config struct {
+	Current  string              `json:"current"`
+	Profiles map[string]*Profile `json:"profiles"`
+}
+
+type Profile struct {
+	AccessToken string `json:"accessToken"`
+	// 平台接口地址，为空时使用默认地址
+	APIHost string `json:"apiHost,omitempty"`
+	// 模板文件目录，设置后 --template 可以使用模板名称
+	Templates string `json:"templates,omitempty"`
+}
+
+func defaultConfigPath() string {
+	if path := os.Getenv("SM_CONFIG"); path != "" {
+		return path
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "sm.json"
+	}
+	return filepath.Join(home, ".config", "sm", "config.json")
+}
+
+func loadConfig(path string) (*Config, error) {
+	c := &Config{Profiles: make(map[string]*Profile)}
+
+	b, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return c, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err = json.Unmarshal(b, c); err != nil {
+		return nil, fmt.Errorf("%s: %s", path, err)
+	}
+	if c.Profiles == nil {
+		c.Profiles = make(map[string]*Profile)
+	}
+	return c, nil
+}
+
+func (c *Config) save(path string) error {
+	b, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err = os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, b, 0600)
+}
+
+func (c *Config) names() []string {
+	names := make([]string, 0, len(c.Profiles))
+	for name := range c.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// profile 按 name、$SM_PROFILE、当前 profile 的顺序选择 profile，
+// 设置了 $SM_ACCESS_TOKEN 时覆盖 profile 中的 access token
+func (c *Config) profile(name string) (*Profile, error) {
+	if name == "" {
+		name = os.Getenv("SM_PROFILE")
+	}
+	if name == "" {
+		name = c.Current
+	}
+
+	p := &Profile{}
+	if name != "" {
+		found, ok := c.Profiles[name]
+		if !ok {
+			return nil, fmt.Errorf("profile %q not found", name)
+		}
+		*p = *found
+	}
+
+	if token := os.Getenv("SM_ACCESS_TOKEN"); token != "" {
+		p.AccessToken = token
+	}
+	if p.AccessToken == "" {
+		return nil, errors.New("no access token, run `sm profile set <name> -token <token>` or set $SM_ACCESS_TOKEN")
+	}
+	return p, nil
+}
@@ -0,0 +1,481 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+
+	go_sdk "github.com/super-message/go-sdk"
+)
+
+const devUsage = `commands:
+  ls                                 list messages of the current user
+  show <message>                     render a message, buttons are numbered
+  click <message> <n> [name=value]   tap the n-th button of a message with form values
+  menu <method> <path> [name=value]  send a request from the channel menu
+  user <openID>                      switch to another user
+  help                               show this help
+  quit                               exit`
+
+// runDev 在本地运行一个模拟的平台接口以及终端中的 App 模拟器，开发处理函数时不需要 App 能够访问开发者服务器：
+//      sm dev -templates ./templates -app http://127.0.0.1:10086
+//      SM_API=http://127.0.0.1:8321 go run .
+// 开发者服务器通过 Client 推送的消息会出现在模拟器中，点击按钮时模拟器会带上 _rt、_cid、_id、_lid、_tid、_tv、_rv 等参数
+// 请求开发者服务器，并按返回的 Response 更新、删除消息或显示提示。_rt 由模拟器签发，可以通过模拟的平台接口验证
+func runDev(args []string) (interface{}, error) {
+	fs, g := newFlagSet("dev")
+	platformAddr := fs.String("platform", "127.0.0.1:8321", "listen address of the local platform api")
+	app := fs.String("app", "http://127.0.0.1:10086", "base url of the developer server handling app requests")
+	channelID := fs.String("channel", "dev-channel", "channel id sent as _cid")
+	openID := fs.String("user", "dev-user", "open id of the simulated user")
+	if positional := parse(fs, args); len(positional) > 0 {
+		return nil, fmt.Errorf("unexpected argument %q", positional[0])
+	}
+
+	templates := g.templates
+	if templates == "" {
+		if config, err := loadConfig(g.config); err == nil {
+			if p, err := config.profile(g.profile); err == nil {
+				templates = p.Templates
+			}
+		}
+	}
+	if templates == "" {
+		return nil, errors.New("-templates is required to render messages")
+	}
+
+	registry := go_sdk.NewTemplateRegistry()
+	if err := registry.LoadDir(templates); err != nil {
+		return nil, err
+	}
+
+	s := &simulator{
+		store:     newDevStore(),
+		registry:  registry,
+		app:       strings.TrimRight(*app, "/"),
+		channelID: *channelID,
+		openID:    *openID,
+		out:       os.Stdout,
+		tokens:    make(map[string]string),
+	}
+
+	platform := &devPlatform{store: s.store, notify: s.printf}
+	go func() {
+		if err := http.ListenAndServe(*platformAddr, platform); err != nil {
+			s.printf("platform: %s", err)
+			os.Exit(1)
+		}
+	}()
+
+	s.printf("local platform api listening on http://%s", *platformAddr)
+	s.printf("start your server with SM_API=http://%s to send messages to the simulator", *platformAddr)
+	s.printf("%s", devUsage)
+	s.repl(os.Stdin)
+	return nil, nil
+}
+
+// simulator 在终端中模拟 App 客户端
+type simulator struct {
+	store     *devStore
+	registry  *go_sdk.TemplateRegistry
+	app       string
+	channelID string
+	openID    string
+	tokens    map[string]string
+
+	outMutex sync.Mutex
+	out      io.Writer
+}
+
+func (s *simulator) printf(format string, args ...interface{}) {
+	s.outMutex.Lock()
+	fmt.Fprintf(s.out, format+"\n", args...)
+	s.outMutex.Unlock()
+}
+
+func (s *simulator) repl(in io.Reader) {
+	scanner := bufio.NewScanner(in)
+	for {
+		s.outMutex.Lock()
+		fmt.Fprintf(s.out, "%s> ", s.openID)
+		s.outMutex.Unlock()
+
+		if !scanner.Scan() {
+			return
+		}
+
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+
+		if err := s.exec(fields[0], fields[1:]); err == io.EOF {
+			return
+		} else if err != nil {
+			s.printf("error: %s", err)
+		}
+	}
+}
+
+func (s *simulator) exec(cmd string, args []string) error {
+	switch cmd {
+	case "ls":
+		for _, m := range s.store.list(s.openID) {
+			s.printf("%-6s %-24s %s v%d", m.key(), m.Title, m.TemplateID, m.TemplateVersion)
+		}
+	case "show":
+		if len(args) != 1 {
+			return errors.New("usage: show <message>")
+		}
+		m, err := s.message(args[0])
+		if err != nil {
+			return err
+		}
+		_, err = s.show(m)
+		return err
+	case "click":
+		if len(args) < 2 {
+			return errors.New("usage: click <message> <n> [name=value ...]")
+		}
+		m, err := s.message(args[0])
+		if err != nil {
+			return err
+		}
+		n, err := strconv.Atoi(args[1])
+		if err != nil {
+			return fmt.Errorf("invalid button number %q", args[1])
+		}
+
+		var r *renderer
+		s.store.update(func() { r, err = s.render(m) })
+		if err != nil {
+			return err
+		}
+		if n < 1 || n > len(r.actions) {
+			return fmt.Errorf("message %s has no button %d", m.key(), n)
+		}
+		a := r.actions[n-1]
+		return s.request(m, a.method, a.path, args[2:])
+	case "menu":
+		if len(args) < 2 {
+			return errors.New("usage: menu <method> <path> [name=value ...]")
+		}
+		// 从菜单发起的请求会在 App 中生成一条本地消息，处理函数通过更新这条消息展示内容
+		m := s.store.add(&devMessage{Title: args[1]}, true)
+		err := s.request(m, strings.ToUpper(args[0]), args[1], args[2:])
+		if _, ok := s.store.get(m.key()); ok && m.TemplateID == "" {
+			s.store.remove(m)
+		}
+		return err
+	case "user":
+		if len(args) != 1 {
+			return errors.New("usage: user <openID>")
+		}
+		s.openID = args[0]
+	case "help":
+		s.printf("%s", devUsage)
+	case "quit", "exit":
+		return io.EOF
+	default:
+		return fmt.Errorf("unknown command %q, run help for commands", cmd)
+	}
+	return nil
+}
+
+func (s *simulator) message(key string) (*devMessage, error) {
+	m, ok := s.store.get(key)
+	if !ok || !m.visibleTo(s.openID) {
+		return nil, fmt.Errorf("message %s not found", key)
+	}
+	return m, nil
+}
+
+// render 使用消息的模板渲染消息，调用时需要持有 store 的锁
+func (s *simulator) render(m *devMessage) (*renderer, error) {
+	t, err := s.registry.CurrentByID(m.TemplateID)
+	if err != nil {
+		return nil, err
+	}
+	if t.Version != m.TemplateVersion {
+		if t, err = s.registry.Version(t.Name, m.TemplateVersion); err != nil {
+			return nil, err
+		}
+	}
+	return render(t.Source, m.Data)
+}
+
+func (s *simulator) show(m *devMessage) (*renderer, error) {
+	var r *renderer
+	var err error
+	s.store.update(func() { r, err = s.render(m) })
+	if err != nil {
+		return nil, err
+	}
+
+	s.printf("── %s %s", m.key(), m.Title)
+	for _, line := range r.lines {
+		s.printf("   %s", line)
+	}
+	if m.NoMoreContents {
+		s.printf("   (no more contents)")
+	}
+	return r, nil
+}
+
+func (s *simulator) token() string {
+	if token, ok := s.tokens[s.openID]; ok {
+		return token
+	}
+
+	token := s.store.issueToken(s.openID)
+	s.tokens[s.openID] = token
+	return token
+}
+
+// request 模拟 App 从消息 m 发起请求，并处理开发者服务器返回的 Response
+func (s *simulator) request(m *devMessage, method, path string, values []string) error {
+	u, err := url.Parse(s.app + path)
+	if err != nil {
+		return err
+	}
+
+	query := u.Query()
+	query.Set("_rt", s.token())
+	query.Set("_cid", s.channelID)
+	query.Set("_id", strconv.FormatInt(m.ID, 10))
+	query.Set("_lid", strconv.FormatInt(m.LocalID, 10))
+	if m.TemplateID != "" {
+		query.Set("_tid", m.TemplateID)
+		query.Set("_tv", strconv.Itoa(m.TemplateVersion))
+	}
+	query.Set("_rv", strconv.Itoa(go_sdk.ResponseVersionActions))
+	u.RawQuery = query.Encode()
+
+	var body io.Reader
+	if method != "GET" && method != "DELETE" {
+		b, err := json.Marshal(formValues(values))
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(b)
+	}
+
+	req, err := http.NewRequest(method, u.String(), body)
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	b, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	s.printf("%s %s → %d", method, path, resp.StatusCode)
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected status code %d: %s", resp.StatusCode, bytes.TrimSpace(b))
+	}
+	if len(bytes.TrimSpace(b)) == 0 {
+		return nil
+	}
+
+	response := &go_sdk.Response{}
+	if err = json.Unmarshal(b, response); err != nil {
+		return fmt.Errorf("invalid response: %s", err)
+	}
+	return s.apply(m, response)
+}
+
+// apply 按 App 的处理方式应用 Response，m 为发起请求的消息
+func (s *simulator) apply(m *devMessage, response *go_sdk.Response) error {
+	if d := response.Delete; d != nil {
+		s.applyDelete(d)
+	}
+
+	if u := response.Update; u != nil {
+		if err := s.applyUpdate(m, u); err != nil {
+			return err
+		}
+	}
+
+	if part := response.UpdatePart; part != nil {
+		if err := s.applyUpdatePart(m, part); err != nil {
+			return err
+		}
+	}
+
+	if n := response.New; n != nil {
+		if err := s.applyNew(n); err != nil {
+			return err
+		}
+	}
+
+	// Actions 按顺序执行，某个操作失败时继续执行后面的操作
+	if response.Version >= go_sdk.ResponseVersionActions {
+		for i, a := range response.Actions {
+			var err error
+			switch {
+			case a.Delete != nil:
+				s.applyDelete(a.Delete)
+			case a.Update != nil:
+				if target, ok := s.store.find(a.Update.ID, a.Update.LocalID); ok {
+					err = s.applyUpdate(target, a.Update)
+				} else {
+					err = errors.New("message not found")
+				}
+			case a.UpdatePart != nil && a.UpdatePart.UpdatePart != nil:
+				if target, ok := s.store.find(a.UpdatePart.ID, a.UpdatePart.LocalID); ok {
+					err = s.applyUpdatePart(target, a.UpdatePart.UpdatePart)
+				} else {
+					err = errors.New("message not found")
+				}
+			case a.New != nil:
+				err = s.applyNew(a.New)
+			}
+			if err != nil {
+				s.printf("action %d failed: %s", i, err)
+			}
+		}
+	}
+
+	if d := response.Dismiss; d != nil {
+		kinds := map[go_sdk.TipType]string{go_sdk.Info: "info", go_sdk.Success: "success", go_sdk.Warning: "warning", go_sdk.Error: "error"}
+		s.printf("[%s] %s", kinds[d.Type], d.Tip)
+	}
+	return nil
+}
+
+func (s *simulator) applyDelete(d *go_sdk.DeleteMessage) {
+	if target, ok := s.store.find(d.ID, d.LocalID); ok {
+		s.store.remove(target)
+		s.printf("message %s deleted", target.key())
+	}
+}
+
+// applyUpdate 更新 u 指定的消息，找不到时更新 m
+func (s *simulator) applyUpdate(m *devMessage, u *go_sdk.UpdateMessage) error {
+	target, ok := s.store.find(u.ID, u.LocalID)
+	if !ok {
+		target = m
+	}
+
+	data, err := toData(u.Data)
+	if err != nil {
+		return err
+	}
+	s.store.update(func() {
+		if u.TemplateID != "" {
+			target.TemplateID, target.TemplateVersion = u.TemplateID, u.TemplateVersion
+		}
+		if u.Title != "" {
+			target.Title = u.Title
+		}
+		target.Data = data
+		target.NoMoreContents = false
+	})
+	_, err = s.show(target)
+	return err
+}
+
+func (s *simulator) applyUpdatePart(m *devMessage, part *go_sdk.UpdatePart) error {
+	var err error
+	s.store.update(func() { err = applyPart(m, part) })
+	if err != nil {
+		return err
+	}
+	_, err = s.show(m)
+	return err
+}
+
+func (s *simulator) applyNew(n *go_sdk.NewMessage) error {
+	data, err := toData(n.Data)
+	if err != nil {
+		return err
+	}
+	created := s.store.add(&devMessage{
+		TemplateID:      n.TemplateID,
+		TemplateVersion: n.TemplateVersion,
+		Title:           n.Title,
+		Data:            data,
+	}, true)
+	_, err = s.show(created)
+	return err
+}
+
+// applyPart 在消息数据的副本上应用局部更新，全部操作成功后才替换消息数据，调用方需持有 devStore 的锁
+func applyPart(m *devMessage, part *go_sdk.UpdatePart) error {
+	data, err := toData(m.Data)
+	if err != nil {
+		return err
+	}
+	if err = part.Apply(data); err != nil {
+		return err
+	}
+
+	m.Data = data
+	m.NoMoreContents = m.NoMoreContents || part.NoMoreContents
+	return nil
+}
+
+// toData 将 Response 中的消息数据转换为 map[string]interface{}
+func toData(v interface{}) (map[string]interface{}, error) {
+	var data map[string]interface{}
+	if v != nil {
+		b, err := json.Marshal(v)
+		if err != nil {
+			return nil, err
+		}
+		if err = json.Unmarshal(b, &data); err != nil {
+			return nil, err
+		}
+	}
+
+	// 消息数据始终是一个对象，nil 会导致 UpdatePart.Apply 失败
+	if data == nil {
+		data = map[string]interface{}{}
+	}
+	return data, nil
+}
+
+// formValues 将 name=value 形式的参数转换为请求数据，值为 JSON 时按 JSON 解析，
+// 名称以 [] 结尾的参数为数组，比如 list[]=1 list[]=2
+func formValues(values []string) map[string]interface{} {
+	form := make(map[string]interface{}, len(values))
+	for _, kv := range values {
+		i := strings.Index(kv, "=")
+		if i < 0 {
+			continue
+		}
+
+		name := kv[:i]
+		var value interface{}
+		if err := json.Unmarshal([]byte(kv[i+1:]), &value); err != nil {
+			value = kv[i+1:]
+		}
+
+		if strings.HasSuffix(name, "[]") {
+			list, _ := form[name].([]interface{})
+			form[name] = append(list, value)
+			continue
+		}
+		form[name] = value
+	}
+	return form
+}
@@ -0,0 +1,268 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	go_sdk "github.com/super-message/go-sdk"
+)
+
+// devMessage 为本地平台保存的消息，LocalID 不为 0 的是 App 本地生成的消息（比如通过菜单发起请求生成的），平台不会保存
+type devMessage struct {
+	ID              int64                  `json:"id,omitempty"`
+	LocalID         int64                  `json:"localID,omitempty"`
+	Recipients      []string               `json:"recipients,omitempty"`
+	ToAll           bool                   `json:"toAll,omitempty"`
+	TemplateID      string                 `json:"templateID"`
+	TemplateVersion int                    `json:"templateVersion"`
+	Title           string                 `json:"title"`
+	Data            map[string]interface{} `json:"data"`
+	NoMoreContents  bool                   `json:"noMoreContents,omitempty"`
+}
+
+func (m *devMessage) key() string {
+	if m.ID != 0 {
+		return strconv.FormatInt(m.ID, 10)
+	}
+	return "L" + strconv.FormatInt(m.LocalID, 10)
+}
+
+func (m *devMessage) visibleTo(openID string) bool {
+	if m.ToAll || m.LocalID != 0 {
+		return true
+	}
+	for _, r := range m.Recipients {
+		if r == openID {
+			return true
+		}
+	}
+	return false
+}
+
+// devStore 保存本地平台的消息以及 App 模拟器签发的 request token
+type devStore struct {
+	mutex       sync.Mutex
+	nextID      int64
+	nextLocalID int64
+	messages    map[string]*devMessage
+	tokens      map[string]string
+}
+
+func newDevStore() *devStore {
+	return &devStore{
+		messages: make(map[string]*devMessage),
+		tokens:   make(map[string]string),
+	}
+}
+
+// add 保存消息，local 为 true 时作为 App 本地生成的消息
+func (s *devStore) add(m *devMessage, local bool) *devMessage {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if local {
+		s.nextLocalID++
+		m.ID, m.LocalID = 0, s.nextLocalID
+	} else {
+		s.nextID++
+		m.ID, m.LocalID = s.nextID, 0
+	}
+
+	if m.Data == nil {
+		m.Data = map[string]interface{}{}
+	}
+	s.messages[m.key()] = m
+	return m
+}
+
+func (s *devStore) get(key string) (*devMessage, bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	m, ok := s.messages[key]
+	return m, ok
+}
+
+// find 根据消息 ID 或本地消息 ID 查找消息
+func (s *devStore) find(id, localID int64) (*devMessage, bool) {
+	if id != 0 {
+		return s.get(strconv.FormatInt(id, 10))
+	}
+	return s.get("L" + strconv.FormatInt(localID, 10))
+}
+
+// update 在锁内修改消息，避免与 App 模拟器的读取冲突
+func (s *devStore) update(fn func()) {
+	s.mutex.Lock()
+	fn()
+	s.mutex.Unlock()
+}
+
+func (s *devStore) remove(m *devMessage) {
+	s.mutex.Lock()
+	delete(s.messages, m.key())
+	s.mutex.Unlock()
+}
+
+// list 返回 openID 可见的消息，按创建顺序排列
+func (s *devStore) list(openID string) []*devMessage {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	var list []*devMessage
+	for _, m := range s.messages {
+		if m.visibleTo(openID) {
+			list = append(list, m)
+		}
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if (list[i].ID == 0) != (list[j].ID == 0) {
+			return list[i].ID != 0
+		}
+		return list[i].ID+list[i].LocalID < list[j].ID+list[j].LocalID
+	})
+	return list
+}
+
+func (s *devStore) issueToken(openID string) string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	token := fmt.Sprintf("dev-%s-%d", openID, time.Now().UnixNano())
+	s.tokens[token] = openID
+	return token
+}
+
+func (s *devStore) member(token string) (string, bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	openID, ok := s.tokens[token]
+	return openID, ok
+}
+
+// devPlatform 在本地模拟平台接口，开发者服务器设置 SM_API 指向它即可调用，不会验证 access token
+type devPlatform struct {
+	store  *devStore
+	notify func(format string, args ...interface{})
+}
+
+func (p *devPlatform) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch {
+	case r.URL.Path == "/v1/user/verify" && r.Method == "GET":
+		openID, ok := p.store.member(r.URL.Query().Get("token"))
+		if !ok {
+			p.fail(w, 10001, "invalid request token")
+			return
+		}
+		p.ok(w, go_sdk.Member{OpenID: openID, ChannelCreator: true, ExpiredAt: time.Now().Add(time.Hour).Unix()})
+	case r.URL.Path == "/v1/messages" && r.Method == "POST":
+		cmr := &go_sdk.CreateMessageRequest{}
+		if err := json.NewDecoder(r.Body).Decode(cmr); err != nil {
+			p.fail(w, 400, err.Error())
+			return
+		}
+		m := p.create(cmr)
+		p.ok(w, map[string]int64{"id": m.ID})
+	case r.URL.Path == "/v1/messages/batch" && r.Method == "POST":
+		batch := &struct {
+			Messages []*go_sdk.CreateMessageRequest `json:"messages"`
+		}{}
+		if err := json.NewDecoder(r.Body).Decode(batch); err != nil {
+			p.fail(w, 400, err.Error())
+			return
+		}
+		results := make([]map[string]int64, len(batch.Messages))
+		for i, cmr := range batch.Messages {
+			results[i] = map[string]int64{"id": p.create(cmr).ID}
+		}
+		p.ok(w, map[string]interface{}{"results": results})
+	case r.URL.Path == "/v1/messages" && r.Method == "PUT":
+		umr := &go_sdk.UpdateMessageRequest{}
+		if err := json.NewDecoder(r.Body).Decode(umr); err != nil {
+			p.fail(w, 400, err.Error())
+			return
+		}
+		m, ok := p.store.find(umr.ID, 0)
+		if !ok {
+			p.notFound(w)
+			return
+		}
+		data := umr.Data
+		if data == nil {
+			data = map[string]interface{}{}
+		}
+		p.store.update(func() {
+			m.TemplateID, m.TemplateVersion, m.Title = umr.TemplateID, int(umr.TemplateVersion), umr.Title
+			m.Data = data
+		})
+		p.notify("platform: message %d updated", m.ID)
+		p.ok(w, nil)
+	case r.URL.Path == "/v1/messages" && r.Method == "PATCH":
+		patch := &struct {
+			ID int64 `json:"id"`
+			go_sdk.UpdatePart
+		}{}
+		if err := json.NewDecoder(r.Body).Decode(patch); err != nil {
+			p.fail(w, 400, err.Error())
+			return
+		}
+		m, ok := p.store.find(patch.ID, 0)
+		if !ok {
+			p.notFound(w)
+			return
+		}
+		var err error
+		p.store.update(func() { err = applyPart(m, &patch.UpdatePart) })
+		if err != nil {
+			p.fail(w, 400, err.Error())
+			return
+		}
+		p.notify("platform: message %d patched", m.ID)
+		p.ok(w, nil)
+	case r.URL.Path == "/v1/messages" && r.Method == "DELETE":
+		id, _ := strconv.ParseInt(r.URL.Query().Get("id"), 10, 64)
+		m, ok := p.store.find(id, 0)
+		if !ok {
+			p.notFound(w)
+			return
+		}
+		p.store.remove(m)
+		p.notify("platform: message %d deleted", m.ID)
+		p.ok(w, nil)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func (p *devPlatform) create(cmr *go_sdk.CreateMessageRequest) *devMessage {
+	m := p.store.add(&devMessage{
+		Recipients:      cmr.Recipients,
+		ToAll:           cmr.ToAll,
+		TemplateID:      cmr.TemplateID,
+		TemplateVersion: int(cmr.TemplateVersion),
+		Title:           cmr.Title,
+		Data:            cmr.Data,
+	}, false)
+	p.notify("platform: message %d created: %s", m.ID, m.Title)
+	return m
+}
+
+func (p *devPlatform) ok(w http.ResponseWriter, data interface{}) {
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{"code": 0, "data": data})
+}
+
+func (p *devPlatform) fail(w http.ResponseWriter, code int, message string) {
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{"code": code, "message": message})
+}
+
+// notFound 与平台一致，消息不存在时返回 HTTP 404
+func (p *devPlatform) notFound(w http.ResponseWriter) {
+	w.WriteHeader(http.StatusNotFound)
+	p.fail(w, http.StatusNotFound, "message not found")
+}
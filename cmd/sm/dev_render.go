@@ -0,0 +1,228 @@
+package main
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// markup 为模板源码解析后的节点，文本节点的 name 为空
+type markup struct {
+	name     string
+	attrs    []xml.Attr
+	text     string
+	children []*markup
+}
+
+func (n *markup) attr(space, name string) (string, bool) {
+	for _, a := range n.attrs {
+		if a.Name.Space == space && strings.EqualFold(a.Name.Local, name) {
+			return a.Value, true
+		}
+	}
+	return "", false
+}
+
+// parseMarkup 解析模板源码，源码为类 HTML 的片段，比如 <Button api:post="/todo">添加</Button>
+func parseMarkup(source string) (*markup, error) {
+	decoder := xml.NewDecoder(strings.NewReader("<root>" + source + "</root>"))
+	decoder.Strict = false
+	decoder.AutoClose = xml.HTMLAutoClose
+	decoder.Entity = xml.HTMLEntity
+
+	// 跳过包裹源码的 <root>
+	if _, err := decoder.Token(); err != nil {
+		return nil, err
+	}
+
+	root := &markup{name: "root"}
+	stack := []*markup{root}
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			return root, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		parent := stack[len(stack)-1]
+		switch t := token.(type) {
+		case xml.StartElement:
+			n := &markup{name: t.Name.Local, attrs: t.Attr}
+			parent.children = append(parent.children, n)
+			stack = append(stack, n)
+		case xml.EndElement:
+			if len(stack) > 1 {
+				stack = stack[:len(stack)-1]
+			}
+		case xml.CharData:
+			if text := strings.TrimSpace(string(t)); text != "" {
+				parent.children = append(parent.children, &markup{text: text})
+			}
+		}
+	}
+}
+
+// action 为消息中可以点击的按钮
+type action struct {
+	label  string
+	method string
+	path   string
+}
+
+// renderer 将模板渲染为终端中显示的文本，按钮按出现顺序编号
+type renderer struct {
+	lines   []string
+	actions []action
+}
+
+func render(source string, data map[string]interface{}) (*renderer, error) {
+	root, err := parseMarkup(source)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &renderer{}
+	r.children(root, data, 0)
+	return r, nil
+}
+
+func (r *renderer) line(depth int, format string, args ...interface{}) {
+	r.lines = append(r.lines, strings.Repeat("  ", depth)+fmt.Sprintf(format, args...))
+}
+
+func (r *renderer) children(n *markup, scope map[string]interface{}, depth int) {
+	for _, child := range n.children {
+		r.node(child, scope, depth)
+	}
+}
+
+func (r *renderer) node(n *markup, scope map[string]interface{}, depth int) {
+	if n.name == "" {
+		r.line(depth, "%s", interpolate(n.text, scope))
+		return
+	}
+
+	if expr, ok := n.attr("v", "show"); ok && !truthy(lookup(scope, expr)) {
+		return
+	}
+	if expr, ok := n.attr("v", "hide"); ok && truthy(lookup(scope, expr)) {
+		return
+	}
+
+	switch strings.ToLower(n.name) {
+	case "for":
+		listExpr, _ := n.attr("", "list")
+		item, _ := n.attr("", "item")
+		index, _ := n.attr("", "index")
+		list, _ := lookup(scope, listExpr).([]interface{})
+		for i, v := range list {
+			itemScope := make(map[string]interface{}, len(scope)+2)
+			for k, sv := range scope {
+				itemScope[k] = sv
+			}
+			itemScope[item] = v
+			if index != "" {
+				itemScope[index] = float64(i)
+			}
+			r.children(n, itemScope, depth)
+		}
+	case "button":
+		for _, method := range []string{"get", "post", "put", "delete"} {
+			if path, ok := n.attr("api", method); ok {
+				a := action{label: r.text(n, scope), method: strings.ToUpper(method), path: interpolate(path, scope)}
+				r.actions = append(r.actions, a)
+				r.line(depth, "[%d] %s  (%s %s)", len(r.actions), a.label, a.method, a.path)
+				return
+			}
+		}
+		r.line(depth, "[ ] %s", r.text(n, scope))
+	case "input":
+		name, _ := n.attr("", "name")
+		label, _ := n.attr("", "label")
+		r.line(depth, "✎ %s  (%s=...)", label, name)
+	case "checkbox":
+		name, _ := n.attr("", "name")
+		value, _ := n.attr("", "value")
+		r.line(depth, "☐ %s  (%s=%s)", r.text(n, scope), name, interpolate(value, scope))
+	default:
+		r.children(n, scope, depth)
+	}
+}
+
+// text 返回节点内的文本
+func (r *renderer) text(n *markup, scope map[string]interface{}) string {
+	var texts []string
+	for _, child := range n.children {
+		if child.name == "" {
+			texts = append(texts, interpolate(child.text, scope))
+		} else {
+			texts = append(texts, r.text(child, scope))
+		}
+	}
+	return strings.Join(texts, " ")
+}
+
+var interpolation = regexp.MustCompile(`\{\{\s*([^}]*?)\s*\}\}`)
+
+func interpolate(s string, scope map[string]interface{}) string {
+	return interpolation.ReplaceAllStringFunc(s, func(m string) string {
+		return format(lookup(scope, interpolation.FindStringSubmatch(m)[1]))
+	})
+}
+
+func lookup(scope map[string]interface{}, expr string) interface{} {
+	var cur interface{} = scope
+	for _, key := range strings.Split(strings.TrimSpace(expr), ".") {
+		switch c := cur.(type) {
+		case map[string]interface{}:
+			cur = c[key]
+		case []interface{}:
+			i, err := strconv.Atoi(key)
+			if err != nil || i < 0 || i >= len(c) {
+				return nil
+			}
+			cur = c[i]
+		default:
+			return nil
+		}
+	}
+	return cur
+}
+
+func truthy(v interface{}) bool {
+	switch v := v.(type) {
+	case nil:
+		return false
+	case bool:
+		return v
+	case string:
+		return v != ""
+	case float64:
+		return v != 0
+	case []interface{}:
+		return len(v) > 0
+	case map[string]interface{}:
+		return len(v) > 0
+	}
+	return true
+}
+
+func format(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	}
+
+	b, _ := json.Marshal(v)
+	return string(b)
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestParseMarkup(t *testing.T) {
+	Convey("Template source is parsed into nodes", t, func() {
+		root, err := parseMarkup(`<Text>标题</Text><Button api:post="/todo" >添加<br></Button>`)
+		So(err, ShouldBeNil)
+		So(root.children, ShouldHaveLength, 2)
+
+		text := root.children[0]
+		So(text.name, ShouldEqual, "Text")
+		So(text.children, ShouldHaveLength, 1)
+		So(text.children[0].text, ShouldEqual, "标题")
+
+		button := root.children[1]
+		path, ok := button.attr("api", "POST")
+		So(ok, ShouldBeTrue)
+		So(path, ShouldEqual, "/todo")
+		_, ok = button.attr("", "post")
+		So(ok, ShouldBeFalse)
+		So(button.children[0].text, ShouldEqual, "添加")
+	})
+}
+
+func TestLookupAndInterpolate(t *testing.T) {
+	scope := map[string]interface{}{
+		"title": "todo",
+		"count": float64(3),
+		"owner": map[string]interface{}{"name": "u1"},
+		"list":  []interface{}{map[string]interface{}{"id": float64(7)}},
+	}
+
+	Convey("Expressions are resolved in scope", t, func() {
+		So(lookup(scope, "title"), ShouldEqual, "todo")
+		So(lookup(scope, " owner.name "), ShouldEqual, "u1")
+		So(lookup(scope, "list.0.id"), ShouldEqual, 7)
+		So(lookup(scope, "list.1.id"), ShouldBeNil)
+		So(lookup(scope, "list.x"), ShouldBeNil)
+		So(lookup(scope, "title.x"), ShouldBeNil)
+		So(lookup(scope, "missing"), ShouldBeNil)
+	})
+
+	Convey("Placeholders are replaced with formatted values", t, func() {
+		So(interpolate("{{title}} ({{ count }})", scope), ShouldEqual, "todo (3)")
+		So(interpolate("/todos/{{list.0.id}}", scope), ShouldEqual, "/todos/7")
+		So(interpolate("{{owner}}", scope), ShouldEqual, `{"name":"u1"}`)
+		So(interpolate("[{{missing}}]", scope), ShouldEqual, "[]")
+	})
+
+	Convey("Values are truthy like in templates", t, func() {
+		So(truthy(nil), ShouldBeFalse)
+		So(truthy(false), ShouldBeFalse)
+		So(truthy(""), ShouldBeFalse)
+		So(truthy(float64(0)), ShouldBeFalse)
+		So(truthy([]interface{}{}), ShouldBeFalse)
+		So(truthy(map[string]interface{}{}), ShouldBeFalse)
+		So(truthy("a"), ShouldBeTrue)
+		So(truthy(float64(1)), ShouldBeTrue)
+		So(truthy(scope["list"]), ShouldBeTrue)
+	})
+}
+
+func TestRender(t *testing.T) {
+	source := `<Text>{{title}}</Text>
+<For list="list" item="todo" index="i">
+	<Text v:hide="todo.done">{{i}}. {{todo.name}}</Text>
+	<Button api:post="/todos/{{todo.id}}/done">完成 {{todo.name}}</Button>
+</For>
+<Button v:show="more" api:get="/todos/more?cursor={{cursor}}">更多</Button>
+<Button>无操作</Button>`
+
+	Convey("Lists and buttons are rendered", t, func() {
+		r, err := render(source, map[string]interface{}{
+			"title":  "待办",
+			"cursor": "c1",
+			"more":   true,
+			"list": []interface{}{
+				map[string]interface{}{"id": float64(1), "name": "a", "done": false},
+				map[string]interface{}{"id": float64(2), "name": "b", "done": true},
+			},
+		})
+		So(err, ShouldBeNil)
+		So(r.lines, ShouldResemble, []string{
+			"待办",
+			"0. a",
+			"[1] 完成 a  (POST /todos/1/done)",
+			"[2] 完成 b  (POST /todos/2/done)",
+			"[3] 更多  (GET /todos/more?cursor=c1)",
+			"[ ] 无操作",
+		})
+		So(r.actions, ShouldResemble, []action{
+			{label: "完成 a", method: "POST", path: "/todos/1/done"},
+			{label: "完成 b", method: "POST", path: "/todos/2/done"},
+			{label: "更多", method: "GET", path: "/todos/more?cursor=c1"},
+		})
+	})
+
+	Convey("Hidden nodes and empty lists render nothing", t, func() {
+		r, err := render(source, map[string]interface{}{"title": "空"})
+		So(err, ShouldBeNil)
+		So(r.lines, ShouldResemble, []string{"空", "[ ] 无操作"})
+		So(r.actions, ShouldBeEmpty)
+	})
+}
@@ -0,0 +1,176 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+	go_sdk "github.com/super-message/go-sdk"
+)
+
+const devTodoSource = `<Text>{{title}}</Text>
+<For list="list" item="todo" index="i">
+	<Button api:post="/todos/{{i}}/done">{{todo}}</Button>
+</For>`
+
+func newTestSimulator(app string) (*simulator, *bytes.Buffer) {
+	registry := go_sdk.NewTemplateRegistry()
+	_ = registry.Register(&go_sdk.Template{Name: "todo", ID: "t-todo", Version: 1, Source: devTodoSource})
+
+	out := &bytes.Buffer{}
+	return &simulator{
+		store:     newDevStore(),
+		registry:  registry,
+		app:       app,
+		channelID: "c1",
+		openID:    "u1",
+		out:       out,
+		tokens:    make(map[string]string),
+	}, out
+}
+
+func TestDevPlatform(t *testing.T) {
+	s, _ := newTestSimulator("")
+	platform := httptest.NewServer(&devPlatform{store: s.store, notify: s.printf})
+	defer platform.Close()
+
+	Convey("Messages are created, updated and patched through the local platform", t, func() {
+		client := go_sdk.NewClient("token", nil, go_sdk.WithAPIHost(platform.URL))
+
+		id, err := client.CreateMessage(&go_sdk.CreateMessageRequest{
+			Recipients: []string{"u1"},
+			MessageContentRequest: go_sdk.MessageContentRequest{
+				TemplateID: "t-todo", TemplateVersion: 1, Title: "待办",
+				Data: map[string]interface{}{"title": "待办", "list": []string{"a"}},
+			},
+		})
+		So(err, ShouldBeNil)
+
+		// 更新时没有数据，之后的局部更新仍然可以应用
+		err = client.UpdateMessage(&go_sdk.UpdateMessageRequest{ID: id, MessageContentRequest: go_sdk.MessageContentRequest{
+			TemplateID: "t-todo", TemplateVersion: 1, Title: "新的待办",
+		}})
+		So(err, ShouldBeNil)
+
+		ops := go_sdk.NewUpdatePart()
+		ops.AddOpSet(go_sdk.NewSet().Add("title", "新的待办"))
+		So(client.PatchMessage(id, ops), ShouldBeNil)
+
+		m, ok := s.store.find(id, 0)
+		So(ok, ShouldBeTrue)
+		So(m.Title, ShouldEqual, "新的待办")
+		So(m.Data, ShouldResemble, map[string]interface{}{"title": "新的待办"})
+
+		So(client.PatchMessage(id+1, ops), ShouldResemble, &go_sdk.StatusError{StatusCode: http.StatusNotFound})
+
+		// 部分操作失败时消息数据保持不变
+		ops = go_sdk.NewUpdatePart()
+		ops.AddOpSet(go_sdk.NewSet().Add("title", "不会生效"))
+		ops.AddOpRemove(go_sdk.NewRemove("title", []int{0}))
+		So(client.PatchMessage(id, ops), ShouldNotBeNil)
+		So(m.Data, ShouldResemble, map[string]interface{}{"title": "新的待办"})
+	})
+
+	Convey("Tracked messages deleted on the local platform are pushed again", t, func() {
+		client := go_sdk.NewClient("token", nil, go_sdk.WithAPIHost(platform.URL))
+		tracker := go_sdk.NewMessageTracker(client, go_sdk.NewMemoryMessageStore())
+		cmr := &go_sdk.CreateMessageRequest{
+			Recipients:            []string{"u1"},
+			MessageContentRequest: go_sdk.MessageContentRequest{TemplateID: "t-todo", TemplateVersion: 1, Title: "待办"},
+		}
+
+		id, created, err := tracker.UpsertMessageFor("todo", cmr)
+		So(err, ShouldBeNil)
+		So(created, ShouldBeTrue)
+		So(client.DeleteMessage(id), ShouldBeNil)
+
+		recreated, created, err := tracker.UpsertMessageFor("todo", cmr)
+		So(err, ShouldBeNil)
+		So(created, ShouldBeTrue)
+		So(recreated, ShouldNotEqual, id)
+	})
+}
+
+func TestSimulatorApply(t *testing.T) {
+	var response *go_sdk.Response
+	var request *http.Request
+	app := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		request = r
+		_ = json.NewEncoder(w).Encode(response)
+	}))
+	defer app.Close()
+
+	Convey("Clicking a button applies the response to the message", t, func() {
+		s, out := newTestSimulator(app.URL)
+		m := s.store.add(&devMessage{
+			Recipients: []string{"u1"}, TemplateID: "t-todo", TemplateVersion: 1, Title: "待办",
+			Data: map[string]interface{}{"title": "待办", "list": []interface{}{"a", "b"}},
+		}, false)
+
+		ops := go_sdk.NewUpdatePart()
+		ops.AddOpRemove(go_sdk.NewRemove("list", []int{0}))
+		ops.MarkNoMoreContents()
+		response = go_sdk.NewResponse().UpdatePartData(ops).ShowSuccess("已完成")
+
+		So(s.exec("click", []string{m.key(), "1"}), ShouldBeNil)
+		So(request.Method, ShouldEqual, "POST")
+		So(request.URL.Path, ShouldEqual, "/todos/0/done")
+		So(request.URL.Query().Get("_id"), ShouldEqual, m.key())
+		So(request.URL.Query().Get("_tid"), ShouldEqual, "t-todo")
+		So(m.Data["list"], ShouldResemble, []interface{}{"b"})
+		So(m.NoMoreContents, ShouldBeTrue)
+		So(out.String(), ShouldContainSubstring, "[1] b  (POST /todos/0/done)")
+		So(out.String(), ShouldContainSubstring, "[success] 已完成")
+
+		So(s.exec("click", []string{m.key(), "2"}), ShouldNotBeNil)
+	})
+
+	Convey("Responses update, create and delete messages", t, func() {
+		s, _ := newTestSimulator(app.URL)
+		m := s.store.add(&devMessage{ToAll: true, TemplateID: "t-todo", TemplateVersion: 1, Title: "待办"}, false)
+
+		err := s.apply(m, &go_sdk.Response{
+			Update: &go_sdk.UpdateMessage{ID: m.ID, Title: "更新", Data: map[string]interface{}{"title": "更新"}},
+			New:    &go_sdk.NewMessage{TemplateID: "t-todo", TemplateVersion: 1, Title: "新消息"},
+		})
+		So(err, ShouldBeNil)
+		So(m.Title, ShouldEqual, "更新")
+		So(m.Data, ShouldResemble, map[string]interface{}{"title": "更新"})
+
+		list := s.store.list("u1")
+		So(list, ShouldHaveLength, 2)
+		So(list[1].LocalID, ShouldNotEqual, 0)
+		So(list[1].Data, ShouldNotBeNil)
+
+		// 数据为 null 的更新不会导致之后的局部更新失败
+		So(s.applyUpdate(m, &go_sdk.UpdateMessage{ID: m.ID, Data: map[string]interface{}(nil)}), ShouldBeNil)
+		ops := go_sdk.NewUpdatePart()
+		ops.AddOpSet(go_sdk.NewSet().Add("title", "局部更新"))
+		So(s.applyUpdatePart(m, ops), ShouldBeNil)
+		So(m.Data, ShouldResemble, map[string]interface{}{"title": "局部更新"})
+
+		ops.AddOpRemove(go_sdk.NewRemove("title", []int{0}))
+		So(s.applyUpdatePart(m, ops), ShouldNotBeNil)
+		So(m.Data, ShouldResemble, map[string]interface{}{"title": "局部更新"})
+
+		err = s.apply(m, &go_sdk.Response{Version: go_sdk.ResponseVersionActions, Actions: []go_sdk.Action{
+			{Delete: &go_sdk.DeleteMessage{LocalID: list[1].LocalID}},
+		}})
+		So(err, ShouldBeNil)
+		So(s.store.list("u1"), ShouldHaveLength, 1)
+	})
+}
+
+func TestFormValues(t *testing.T) {
+	Convey("Form values are parsed as JSON when possible", t, func() {
+		So(formValues([]string{"name=a", "done=true", "count=2", "list[]=1", "list[]=x", "invalid"}), ShouldResemble, map[string]interface{}{
+			"name":   "a",
+			"done":   true,
+			"count":  float64(2),
+			"list[]": []interface{}{float64(1), "x"},
+		})
+	})
+}
@@ -0,0 +1,163 @@
+// sm 是调用平台接口的命令行工具，无需编写代码即可推送公告、修改或删除消息以及验证 request token：
+//      sm profile set prod -token <accessToken> -templates ./templates
+//      sm send -template todo -title "新的待办" -data @todo.json -to openid1,openid2
+//      sm send -template announcement -version 3 -title "维护通知" -data '{"time":"22:00"}' -all
+//      sm update -id 42 -template todo -title "新的待办" -data @todo.json
+//      sm delete 42
+//      sm verify <requestToken>
+//      sm template push ./templates
+//      sm dev -templates ./templates
+//
+// 所有命令的结果都以 JSON 格式输出到标准输出，失败时输出 {"error": "...", "code": ...} 并以状态码 1 退出。
+// 配置文件默认为 ~/.config/sm/config.json，可以通过 -config 或 $SM_CONFIG 指定，
+// -profile 或 $SM_PROFILE 选择 profile，$SM_ACCESS_TOKEN 覆盖 profile 中的 access token
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	go_sdk "github.com/super-message/go-sdk"
+)
+
+type command struct {
+	name  string
+	usage string
+	run   func(args []string) (interface{}, error)
+}
+
+var commands []command
+
+func init() {
+	commands = []command{
+		{"send", "send a message to recipients or all members of the channel", runSend},
+		{"update", "update the template, title and data of a message", runUpdate},
+		{"delete", "delete a message", runDelete},
+		{"verify", "verify a request token and print the member", runVerify},
+		{"template", "upload templates and list template versions", runTemplate},
+		{"profile", "manage access token profiles: list, set, use, delete", runProfile},
+		{"dev", "run a local platform api and an app simulator for development", runDev},
+	}
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+
+	for _, cmd := range commands {
+		if cmd.name != os.Args[1] {
+			continue
+		}
+
+		result, err := cmd.run(os.Args[2:])
+		if ue, ok := err.(*usageError); ok {
+			ue.fs.Usage()
+			os.Exit(2)
+		}
+		if err != nil {
+			fail(err)
+		}
+		if result != nil {
+			output(result)
+		}
+		return
+	}
+
+	usage()
+	os.Exit(2)
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "Usage: sm <command> [flags]")
+	fmt.Fprintln(os.Stderr)
+	for _, cmd := range commands {
+		fmt.Fprintf(os.Stderr, "  %-8s %s\n", cmd.name, cmd.usage)
+	}
+	fmt.Fprintln(os.Stderr)
+	fmt.Fprintln(os.Stderr, "Run 'sm <command> -h' for flags of a command.")
+}
+
+func output(v interface{}) {
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	encoder.SetEscapeHTML(false)
+	_ = encoder.Encode(v)
+}
+
+// usageError 表示命令的参数不正确，由 main 输出命令的用法并以状态码 2 退出
+type usageError struct {
+	fs *flag.FlagSet
+}
+
+func (e *usageError) Error() string {
+	return "invalid arguments for " + e.fs.Name()
+}
+
+type errorOutput struct {
+	Error string `json:"error"`
+	Code  int    `json:"code,omitempty"`
+}
+
+func fail(err error) {
+	out := errorOutput{Error: err.Error()}
+	if re, ok := err.(*go_sdk.APIError); ok {
+		out.Error = re.Message
+		out.Code = re.Code
+	}
+	output(out)
+	os.Exit(1)
+}
+
+// globalFlags 为所有命令共用的参数
+type globalFlags struct {
+	config    string
+	profile   string
+	templates string
+}
+
+func newFlagSet(name string) (*flag.FlagSet, *globalFlags) {
+	fs := flag.NewFlagSet("sm "+name, flag.ExitOnError)
+	g := &globalFlags{}
+	fs.StringVar(&g.config, "config", defaultConfigPath(), "config file")
+	fs.StringVar(&g.profile, "profile", "", "profile to use, defaults to $SM_PROFILE or the current profile")
+	fs.StringVar(&g.templates, "templates", "", "template directory, overrides the templates of the profile")
+	return fs, g
+}
+
+// parse 解析参数，允许参数与位置参数混合出现，比如 sm profile set prod -token xxx，返回位置参数
+func parse(fs *flag.FlagSet, args []string) []string {
+	var positional []string
+	for {
+		_ = fs.Parse(args)
+		if fs.NArg() == 0 {
+			return positional
+		}
+		positional = append(positional, fs.Arg(0))
+		args = fs.Args()[1:]
+	}
+}
+
+func (g *globalFlags) client() (*go_sdk.Client, *Profile, error) {
+	config, err := loadConfig(g.config)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	p, err := config.profile(g.profile)
+	if err != nil {
+		return nil, nil, err
+	}
+	if g.templates != "" {
+		p.Templates = g.templates
+	}
+
+	var opts []go_sdk.ClientOption
+	if p.APIHost != "" {
+		opts = append(opts, go_sdk.WithAPIHost(p.APIHost))
+	}
+	return go_sdk.NewClient(p.AccessToken, nil, opts...), p, nil
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	go_sdk "github.com/super-message/go-sdk"
+)
+
+type pushResult struct {
+	File    string `json:"file"`
+	Name    string `json:"name"`
+	ID      string `json:"id"`
+	Version int    `json:"version"`
+	Changed bool   `json:"changed"`
+}
+
+// runTemplate 管理平台上的模板：
+//      sm template push templates/          创建或发布新版本，并将 ID 和版本号写回模板文件
+//      sm template versions <templateID>    列出模板已发布的版本
+//      sm template get <templateID> -version 2
+func runTemplate(args []string) (interface{}, error) {
+	fs, g := newFlagSet("template")
+	version := fs.Int("version", 0, "template version (get), defaults to the latest version")
+	dryRun := fs.Bool("n", false, "do not write ids and versions back to template files (push)")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: sm template push <file or directory>... | versions <templateID> | get <templateID> [flags]")
+		fs.PrintDefaults()
+	}
+
+	if len(args) == 0 {
+		return nil, &usageError{fs: fs}
+	}
+	action := args[0]
+	positional := parse(fs, args[1:])
+	if len(positional) == 0 {
+		return nil, &usageError{fs: fs}
+	}
+
+	client, _, err := g.client()
+	if err != nil {
+		return nil, err
+	}
+
+	switch action {
+	case "push":
+		files, err := templateFiles(positional)
+		if err != nil {
+			return nil, err
+		}
+		return pushTemplates(client, files, !*dryRun)
+	case "versions":
+		return client.ListTemplateVersions(positional[0])
+	case "get":
+		return client.GetTemplate(positional[0], *version)
+	}
+
+	return nil, &usageError{fs: fs}
+}
+
+// templateFiles 展开参数中的目录，返回所有 .json 模板文件
+func templateFiles(args []string) ([]string, error) {
+	var files []string
+	for _, arg := range args {
+		info, err := os.Stat(arg)
+		if err != nil {
+			return nil, err
+		}
+		if !info.IsDir() {
+			files = append(files, arg)
+			continue
+		}
+
+		matches, err := filepath.Glob(filepath.Join(arg, "*.json"))
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, matches...)
+	}
+	return files, nil
+}
+
+func pushTemplates(client *go_sdk.Client, files []string, write bool) ([]pushResult, error) {
+	results := make([]pushResult, 0, len(files))
+	for _, file := range files {
+		t, err := go_sdk.LoadTemplateFile(file)
+		if err != nil {
+			return results, fmt.Errorf("%s: %s", file, err)
+		}
+
+		changed, err := client.UploadTemplate(t)
+		if err != nil {
+			return results, fmt.Errorf("%s: %s", file, err)
+		}
+
+		if changed && write {
+			if err = go_sdk.SaveTemplateFile(file, t); err != nil {
+				return results, err
+			}
+		}
+
+		results = append(results, pushResult{
+			File:    file,
+			Name:    t.Name,
+			ID:      t.ID,
+			Version: t.Version,
+			Changed: changed,
+		})
+	}
+	return results, nil
+}
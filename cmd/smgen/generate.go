@@ -0,0 +1,355 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"sort"
+	"strings"
+	"unicode"
+
+	go_sdk "github.com/super-message/go-sdk"
+)
+
+var scalarTypes = map[string]string{
+	"string":  "string",
+	"bool":    "bool",
+	"int":     "int",
+	"int64":   "int64",
+	"float64": "float64",
+	"number":  "float64",
+	"any":     "interface{}",
+}
+
+// 常见缩写，生成字段名时保持全大写
+var initialisms = map[string]bool{
+	"API":  true,
+	"HTML": true,
+	"HTTP": true,
+	"ID":   true,
+	"JSON": true,
+	"UID":  true,
+	"URL":  true,
+}
+
+type structField struct {
+	Name string
+	Key  string
+	Type string
+}
+
+type structDef struct {
+	Name   string
+	Fields []structField
+}
+
+// keyPath 表示一个数据绑定的 keypath，Parts 中的空字符串表示数组索引，Type 为该位置的值类型
+type keyPath struct {
+	Name  string
+	Parts []string
+	Type  string
+}
+
+func (k keyPath) indexCount() (n int) {
+	for _, p := range k.Parts {
+		if p == "" {
+			n++
+		}
+	}
+	return
+}
+
+type generator struct {
+	structs  []*structDef
+	keyPaths []keyPath
+	// 已生成的标识符及其来源，用于发现重名
+	names map[string]string
+}
+
+// declare 记录生成的标识符，与已有的标识符重名时返回错误，避免生成无法编译的代码
+func (g *generator) declare(name, source string) error {
+	if g.names == nil {
+		g.names = make(map[string]string)
+	}
+	if other, ok := g.names[name]; ok {
+		return fmt.Errorf("identifier %s is generated for both %s and %s", name, other, source)
+	}
+	g.names[name] = source
+	return nil
+}
+
+// keyPathSource 返回用于错误信息的 keypath 描述，数组索引显示为 []
+func keyPathSource(parts []string) string {
+	if len(parts) == 0 {
+		return "data"
+	}
+	display := make([]string, len(parts))
+	for i, p := range parts {
+		if p == "" {
+			p = "[]"
+		}
+		display[i] = p
+	}
+	return "keypath " + strings.Join(display, ".")
+}
+
+// generate 根据模板生成数据结构及 keypath 的 Go 代码
+func generate(t *go_sdk.Template, pkg, prefix, source string) ([]byte, error) {
+	g := &generator{}
+	for _, name := range []string{prefix + "TemplateName", prefix + "TemplateID", prefix + "TemplateVersion"} {
+		if err := g.declare(name, "template constants"); err != nil {
+			return nil, err
+		}
+	}
+	if _, err := g.resolveStruct(t.Data, prefix+"Data", prefix+"Key", nil); err != nil {
+		return nil, err
+	}
+
+	needStrconv := false
+	for _, k := range g.keyPaths {
+		if k.indexCount() > 0 {
+			needStrconv = true
+			break
+		}
+	}
+
+	buf := new(bytes.Buffer)
+	fmt.Fprintf(buf, "// Code generated by smgen from %s. DO NOT EDIT.\n\n", source)
+	fmt.Fprintf(buf, "package %s\n\n", pkg)
+	fmt.Fprintf(buf, "import (\n")
+	if needStrconv {
+		fmt.Fprintf(buf, "\"strconv\"\n\n")
+	}
+	fmt.Fprintf(buf, "go_sdk \"github.com/super-message/go-sdk\"\n")
+	fmt.Fprintf(buf, ")\n\n")
+
+	fmt.Fprintf(buf, "// %s 模板的名称、ID 和版本号\n", t.Name)
+	fmt.Fprintf(buf, "const (\n")
+	fmt.Fprintf(buf, "%sTemplateName = %q\n", prefix, t.Name)
+	fmt.Fprintf(buf, "%sTemplateID = %q\n", prefix, t.ID)
+	fmt.Fprintf(buf, "%sTemplateVersion = %d\n", prefix, t.Version)
+	fmt.Fprintf(buf, ")\n\n")
+
+	for i, s := range g.structs {
+		if i == 0 {
+			fmt.Fprintf(buf, "// %s 是 %s 模板的数据结构\n", s.Name, t.Name)
+		}
+		fmt.Fprintf(buf, "type %s struct {\n", s.Name)
+		for _, f := range s.Fields {
+			fmt.Fprintf(buf, "%s %s `json:%q`\n", f.Name, f.Type, f.Key)
+		}
+		fmt.Fprintf(buf, "}\n\n")
+	}
+
+	if len(g.keyPaths) > 0 {
+		fmt.Fprintf(buf, "// %s 模板数据的 keypath，用于 UpdatePart 中的各种操作\n", t.Name)
+		fmt.Fprintf(buf, "const (\n")
+		for _, k := range g.keyPaths {
+			if k.indexCount() == 0 {
+				fmt.Fprintf(buf, "%s = %q\n", k.Name, strings.Join(k.Parts, "."))
+			}
+		}
+		fmt.Fprintf(buf, ")\n\n")
+	}
+
+	for _, k := range g.keyPaths {
+		n := k.indexCount()
+		if n == 0 {
+			continue
+		}
+
+		params := make([]string, n)
+		for i := range params {
+			params[i] = string(rune('i' + i))
+		}
+
+		var exprs []string
+		var literal []string
+		idx := 0
+		for _, p := range k.Parts {
+			if p != "" {
+				literal = append(literal, p)
+				continue
+			}
+
+			literal = append(literal, "")
+			exprs = append(exprs, fmt.Sprintf("%q", strings.Join(literal, ".")), "strconv.Itoa("+params[idx]+")")
+			literal = []string{""}
+			idx++
+		}
+		if tail := strings.Join(literal, "."); tail != "" {
+			exprs = append(exprs, fmt.Sprintf("%q", tail))
+		}
+
+		fmt.Fprintf(buf, "func %s(%s int) string {\n", k.Name, strings.Join(params, ", "))
+		fmt.Fprintf(buf, "return %s\n", strings.Join(exprs, " + "))
+		fmt.Fprintf(buf, "}\n\n")
+	}
+
+	if err := g.generateFields(buf, t, prefix); err != nil {
+		return nil, err
+	}
+	return format.Source(buf.Bytes())
+}
+
+// generateFields 生成带类型的字段，用于 go_sdk.NewSetOf 等类型安全的 UpdatePart 操作
+func (g *generator) generateFields(buf *bytes.Buffer, t *go_sdk.Template, prefix string) error {
+	if len(g.keyPaths) == 0 {
+		return nil
+	}
+	for _, k := range g.keyPaths {
+		if err := g.declare(prefix+"Field"+strings.TrimPrefix(k.Name, prefix+"Key"), "field of "+keyPathSource(k.Parts)); err != nil {
+			return err
+		}
+	}
+
+	dataType := prefix + "Data"
+	fieldName := func(k keyPath) string {
+		return prefix + "Field" + strings.TrimPrefix(k.Name, prefix+"Key")
+	}
+
+	fmt.Fprintf(buf, "// %s 模板数据的字段，用于 go_sdk.NewSetOf 等类型安全的 UpdatePart 操作\n", t.Name)
+	fmt.Fprintf(buf, "var (\n")
+	for _, k := range g.keyPaths {
+		if k.indexCount() == 0 {
+			fmt.Fprintf(buf, "%s = go_sdk.NewField[%s, %s](%s)\n", fieldName(k), dataType, k.Type, k.Name)
+		}
+	}
+	fmt.Fprintf(buf, ")\n\n")
+
+	for _, k := range g.keyPaths {
+		n := k.indexCount()
+		if n == 0 {
+			continue
+		}
+
+		params := make([]string, n)
+		for i := range params {
+			params[i] = string(rune('i' + i))
+		}
+
+		fieldType := fmt.Sprintf("go_sdk.Field[%s, %s]", dataType, k.Type)
+		fmt.Fprintf(buf, "func %s(%s int) %s {\n", fieldName(k), strings.Join(params, ", "), fieldType)
+		fmt.Fprintf(buf, "return go_sdk.NewField[%s, %s](%s(%s))\n", dataType, k.Type, k.Name, strings.Join(params, ", "))
+		fmt.Fprintf(buf, "}\n\n")
+	}
+	return nil
+}
+
+func (g *generator) resolve(v interface{}, typeName, keyName string, parts []string) (string, error) {
+	switch v := v.(type) {
+	case string:
+		t, ok := scalarTypes[strings.TrimSpace(v)]
+		if !ok {
+			return "", fmt.Errorf("%s: unknown type %q", strings.Join(parts, "."), v)
+		}
+		return t, nil
+	case map[string]interface{}:
+		return g.resolveStruct(v, typeName, keyName, parts)
+	case []interface{}:
+		if len(v) != 1 {
+			return "", fmt.Errorf("%s: list must contain exactly one element type", strings.Join(parts, "."))
+		}
+
+		itemParts := append(append([]string{}, parts...), "")
+		if err := g.declare(keyName+"Item", keyPathSource(itemParts)); err != nil {
+			return "", err
+		}
+		g.keyPaths = append(g.keyPaths, keyPath{Name: keyName + "Item", Parts: itemParts})
+		item := len(g.keyPaths) - 1
+		// 列表元素为对象时，其字段的 keypath 直接接在列表名称后面
+		itemKeyName := keyName
+		if _, ok := v[0].(map[string]interface{}); !ok {
+			itemKeyName += "Item"
+		}
+		t, err := g.resolve(v[0], typeName+"Item", itemKeyName, itemParts)
+		if err != nil {
+			return "", err
+		}
+		g.keyPaths[item].Type = t
+		return "[]" + t, nil
+	default:
+		return "", fmt.Errorf("%s: unsupported type description %v", strings.Join(parts, "."), v)
+	}
+}
+
+func (g *generator) resolveStruct(m map[string]interface{}, typeName, keyName string, parts []string) (string, error) {
+	if err := g.declare(typeName, "struct of "+keyPathSource(parts)); err != nil {
+		return "", err
+	}
+	s := &structDef{Name: typeName}
+	g.structs = append(g.structs, s)
+
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	names := make(map[string]string, len(keys))
+	for _, k := range keys {
+		name := exportedName(k)
+		if other, ok := names[name]; ok {
+			return "", fmt.Errorf("keys %q and %q map to the same field name %s", other, k, name)
+		}
+		names[name] = k
+
+		fieldParts := append(append([]string{}, parts...), k)
+		if err := g.declare(keyName+name, keyPathSource(fieldParts)); err != nil {
+			return "", err
+		}
+		g.keyPaths = append(g.keyPaths, keyPath{Name: keyName + name, Parts: fieldParts})
+		field := len(g.keyPaths) - 1
+
+		t, err := g.resolve(m[k], typeName+name, keyName+name, fieldParts)
+		if err != nil {
+			return "", err
+		}
+		g.keyPaths[field].Type = t
+		s.Fields = append(s.Fields, structField{Name: name, Key: k, Type: t})
+	}
+
+	return typeName, nil
+}
+
+// exportedName 将 JSON key 或模板名称转换为导出的 Go 标识符，如 todo-list → TodoList，avatarUrl → AvatarURL
+func exportedName(s string) string {
+	var words []string
+	var word []rune
+	flush := func() {
+		if len(word) > 0 {
+			words = append(words, string(word))
+			word = word[:0]
+		}
+	}
+
+	runes := []rune(s)
+	for i, r := range runes {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			flush()
+			continue
+		}
+		if unicode.IsUpper(r) && i > 0 && unicode.IsLower(runes[i-1]) {
+			flush()
+		}
+		word = append(word, r)
+	}
+	flush()
+
+	b := new(strings.Builder)
+	for _, w := range words {
+		if u := strings.ToUpper(w); initialisms[u] {
+			b.WriteString(u)
+			continue
+		}
+
+		r := []rune(w)
+		b.WriteString(strings.ToUpper(string(r[0])) + string(r[1:]))
+	}
+
+	name := b.String()
+	if name == "" || !unicode.IsUpper([]rune(name)[0]) {
+		name = "X" + name
+	}
+	return name
+}
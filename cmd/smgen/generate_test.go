@@ -0,0 +1,77 @@
+package main
+
+import (
+	"flag"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+	go_sdk "github.com/super-message/go-sdk"
+)
+
+var update = flag.Bool("update", false, "update golden files")
+
+func TestGenerate(t *testing.T) {
+	for _, name := range []string{"scalars", "objects", "nested"} {
+		name := name
+		Convey("Generate "+name, t, func() {
+			tpl, err := go_sdk.LoadTemplateFile(filepath.Join("testdata", name+".json"))
+			So(err, ShouldBeNil)
+
+			src, err := generate(tpl, "templates", exportedName(tpl.Name), name+".json")
+			So(err, ShouldBeNil)
+
+			golden := filepath.Join("testdata", name+".golden")
+			if *update {
+				So(ioutil.WriteFile(golden, src, 0644), ShouldBeNil)
+			}
+
+			expected, err := ioutil.ReadFile(golden)
+			So(err, ShouldBeNil)
+			So(string(src), ShouldEqual, string(expected))
+		})
+	}
+
+	Convey("Reject identifiers generated twice", t, func() {
+		tpl, err := go_sdk.LoadTemplateFile(filepath.Join("testdata", "collision.json"))
+		So(err, ShouldBeNil)
+
+		_, err = generate(tpl, "templates", "TodoList", "collision.json")
+		So(err, ShouldNotBeNil)
+		So(err.Error(), ShouldContainSubstring, "TodoListKeyListItem")
+	})
+
+	Convey("Reject keys mapping to the same field name", t, func() {
+		tpl := &go_sdk.Template{Name: "x", Data: map[string]interface{}{"user_id": "int", "userID": "int"}}
+		_, err := generate(tpl, "templates", "X", "x.json")
+		So(err, ShouldNotBeNil)
+	})
+
+	Convey("Reject unknown types", t, func() {
+		tpl := &go_sdk.Template{Name: "x", Data: map[string]interface{}{"a": "uint"}}
+		_, err := generate(tpl, "templates", "X", "x.json")
+		So(err, ShouldNotBeNil)
+		So(strings.Contains(err.Error(), `"uint"`), ShouldBeTrue)
+	})
+}
+
+func TestExportedName(t *testing.T) {
+	Convey("Convert keys and template names to exported identifiers", t, func() {
+		cases := map[string]string{
+			"todo-list":   "TodoList",
+			"title":       "Title",
+			"avatarUrl":   "AvatarURL",
+			"user_id":     "UserID",
+			"apiKey":      "APIKey",
+			"htmlContent": "HTMLContent",
+			"2fa":         "X2fa",
+			"-":           "X",
+			"已完成":         "X已完成",
+		}
+		for in, out := range cases {
+			So(exportedName(in), ShouldEqual, out)
+		}
+	})
+}
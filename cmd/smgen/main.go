@@ -0,0 +1,63 @@
+// smgen 根据消息模板文件生成模板数据的 Go 结构体、UpdatePart 操作使用的 keypath 常量以及带类型的字段，
+// 避免在代码中手写字段名和 keypath，拼写错误以及值类型错误在编译期即可发现。
+//
+// 配合 go generate 使用：
+//      //go:generate go run github.com/super-message/go-sdk/cmd/smgen -in templates/todo-list.json
+//
+// 默认输出到当前目录下的 <模板名称>_data.go 文件，包名取自 go generate 设置的 $GOPACKAGE
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	go_sdk "github.com/super-message/go-sdk"
+)
+
+func main() {
+	in := flag.String("in", "", "template file (required)")
+	out := flag.String("out", "", "output file, defaults to <template name>_data.go")
+	pkg := flag.String("pkg", os.Getenv("GOPACKAGE"), "package name of the generated file")
+	prefix := flag.String("prefix", "", "prefix of generated identifiers, defaults to the template name in CamelCase")
+	flag.Parse()
+
+	if *in == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	if err := run(*in, *out, *pkg, *prefix); err != nil {
+		fmt.Fprintln(os.Stderr, "smgen:", err)
+		os.Exit(1)
+	}
+}
+
+func run(in, out, pkg, prefix string) error {
+	t, err := go_sdk.LoadTemplateFile(in)
+	if err != nil {
+		return fmt.Errorf("%s: %s", in, err)
+	}
+
+	if pkg == "" {
+		pkg = "main"
+	}
+
+	if prefix == "" {
+		prefix = exportedName(t.Name)
+	}
+
+	if out == "" {
+		out = strings.Replace(strings.ToLower(t.Name), "-", "_", -1) + "_data.go"
+	}
+
+	src, err := generate(t, pkg, prefix, filepath.Base(in))
+	if err != nil {
+		return fmt.Errorf("%s: %s", in, err)
+	}
+
+	return ioutil.WriteFile(out, src, 0644)
+}
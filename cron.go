@@ -0,0 +1,163 @@
+package go_sdk
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CronSchedule 为解析后的 cron 重复规则，支持标准的 5 个字段：分 时 日 月 周，如 "30 9 * * 1-5" 表示
+// 工作日的 9:30。每个字段支持 *、数值、范围（1-5）、列表（1,3,5）和步长（*/15、0-30/10），
+// 周字段 0 和 7 均表示周日。另外支持 @hourly、@daily、@weekly、@monthly 以及 @every <duration>，
+// 如 @every 1h30m
+type CronSchedule struct {
+	minute, hour, dom, month, dow uint64
+	// 日和周字段是否为 *，两者都有限制时，满足其中一个即可
+	domStar, dowStar bool
+	every            time.Duration
+}
+
+var cronDescriptors = map[string]string{
+	"@hourly":  "0 * * * *",
+	"@daily":   "0 0 * * *",
+	"@weekly":  "0 0 * * 0",
+	"@monthly": "0 0 1 * *",
+}
+
+// ParseCron 解析 cron 重复规则
+func ParseCron(spec string) (*CronSchedule, error) {
+	spec = strings.TrimSpace(spec)
+	if strings.HasPrefix(spec, "@every ") {
+		d, err := time.ParseDuration(strings.TrimSpace(spec[len("@every "):]))
+		if err != nil {
+			return nil, fmt.Errorf("invalid cron spec %q: %s", spec, err)
+		}
+
+		if d < time.Second {
+			return nil, fmt.Errorf("invalid cron spec %q: interval must be at least 1s", spec)
+		}
+		return &CronSchedule{every: d}, nil
+	}
+
+	if s, ok := cronDescriptors[spec]; ok {
+		spec = s
+	}
+
+	fields := strings.Fields(spec)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("invalid cron spec %q: expected 5 fields", spec)
+	}
+
+	c := &CronSchedule{
+		domStar: fields[2] == "*",
+		dowStar: fields[4] == "*",
+	}
+
+	var err error
+	bounds := [5][2]int{{0, 59}, {0, 23}, {1, 31}, {1, 12}, {0, 7}}
+	targets := [5]*uint64{&c.minute, &c.hour, &c.dom, &c.month, &c.dow}
+	for i, field := range fields {
+		*targets[i], err = parseCronField(field, bounds[i][0], bounds[i][1])
+		if err != nil {
+			return nil, fmt.Errorf("invalid cron spec %q: %s", spec, err)
+		}
+	}
+
+	// 7 与 0 都表示周日
+	if c.dow&(1<<7) != 0 {
+		c.dow |= 1
+	}
+	return c, nil
+}
+
+func parseCronField(field string, min, max int) (bits uint64, err error) {
+	for _, part := range strings.Split(field, ",") {
+		step := 1
+		if i := strings.Index(part, "/"); i >= 0 {
+			step, err = strconv.Atoi(part[i+1:])
+			if err != nil || step < 1 {
+				return 0, fmt.Errorf("invalid step in %q", part)
+			}
+			part = part[:i]
+		}
+
+		lo, hi := min, max
+		switch {
+		case part == "*":
+		case strings.Contains(part, "-"):
+			i := strings.Index(part, "-")
+			lo, err = strconv.Atoi(part[:i])
+			if err != nil {
+				return 0, fmt.Errorf("invalid range %q", part)
+			}
+			hi, err = strconv.Atoi(part[i+1:])
+			if err != nil {
+				return 0, fmt.Errorf("invalid range %q", part)
+			}
+		default:
+			lo, err = strconv.Atoi(part)
+			if err != nil {
+				return 0, fmt.Errorf("invalid value %q", part)
+			}
+			hi = lo
+			if step > 1 {
+				hi = max
+			}
+		}
+
+		if lo < min || hi > max || lo > hi {
+			return 0, fmt.Errorf("value out of range [%d, %d] in %q", min, max, part)
+		}
+
+		for v := lo; v <= hi; v += step {
+			bits |= 1 << uint(v)
+		}
+	}
+
+	return
+}
+
+// Next 返回 t 之后（不含 t）第一个满足规则的时间，五年内都没有满足规则的时间则返回零值
+func (c *CronSchedule) Next(t time.Time) time.Time {
+	if c.every > 0 {
+		return t.Add(c.every)
+	}
+
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	limit := t.AddDate(5, 0, 0)
+	for t.Before(limit) {
+		if c.month&(1<<uint(t.Month())) == 0 {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+
+		if !c.dayMatches(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+
+		if c.hour&(1<<uint(t.Hour())) == 0 {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+			continue
+		}
+
+		if c.minute&(1<<uint(t.Minute())) == 0 {
+			t = t.Add(time.Minute)
+			continue
+		}
+
+		return t
+	}
+
+	return time.Time{}
+}
+
+func (c *CronSchedule) dayMatches(t time.Time) bool {
+	domMatch := c.dom&(1<<uint(t.Day())) != 0
+	dowMatch := c.dow&(1<<uint(t.Weekday())) != 0
+	if c.domStar || c.dowStar {
+		return domMatch && dowMatch
+	}
+	return domMatch || dowMatch
+}
@@ -0,0 +1,48 @@
+package go_sdk
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestCronSchedule(t *testing.T) {
+	// 2020-04-24 为周五
+	base := time.Date(2020, 4, 24, 9, 30, 0, 0, time.UTC)
+
+	Convey("Parse and compute next times", t, func() {
+		cases := []struct {
+			spec string
+			next time.Time
+		}{
+			{"30 9 * * 1-5", time.Date(2020, 4, 27, 9, 30, 0, 0, time.UTC)},
+			{"*/15 * * * *", time.Date(2020, 4, 24, 9, 45, 0, 0, time.UTC)},
+			{"0 0 1 * *", time.Date(2020, 5, 1, 0, 0, 0, 0, time.UTC)},
+			{"0 8 * * 7", time.Date(2020, 4, 26, 8, 0, 0, 0, time.UTC)},
+			{"0 12 13 * 5", time.Date(2020, 4, 24, 12, 0, 0, 0, time.UTC)},
+			{"0 0 29 2 *", time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC)},
+			{"@daily", time.Date(2020, 4, 25, 0, 0, 0, 0, time.UTC)},
+			{"@every 90m", base.Add(90 * time.Minute)},
+		}
+
+		for _, c := range cases {
+			s, err := ParseCron(c.spec)
+			So(err, ShouldBeNil)
+			So(s.Next(base), ShouldEqual, c.next)
+		}
+	})
+
+	Convey("Invalid specs are rejected", t, func() {
+		for _, spec := range []string{"", "* * * *", "60 * * * *", "5-1 * * * *", "*/0 * * * *", "@every 1ms"} {
+			_, err := ParseCron(spec)
+			So(err, ShouldNotBeNil)
+		}
+	})
+
+	Convey("Impossible dates never match", t, func() {
+		s, err := ParseCron("0 0 31 2 *")
+		So(err, ShouldBeNil)
+		So(s.Next(base).IsZero(), ShouldBeTrue)
+	})
+}
@@ -1,5 +1,7 @@
 package main
 
+//go:generate go run github.com/super-message/go-sdk/cmd/smgen -in templates/todo-list.json
+
 import (
 	"context"
 	"encoding/json"
@@ -19,6 +21,11 @@ var (
 )
 
 func main() {
+	// 将 SDK 的日志输出到标准库的 log，也可以直接使用 slog.Default() 等
+	go_sdk.SetLogger(go_sdk.LoggerFunc(func(level go_sdk.LogLevel, msg string, fields map[string]interface{}) {
+		log.Println(level, msg, fields)
+	}))
+
 	// Access token 请到开发者后台查看
 	client = go_sdk.NewClient("RCnXKNJW1AtjmA0Ih2xCAINrawzaM959", go_sdk.NewMemoryCache())
 
@@ -91,21 +98,20 @@ func (h authMiddlewareHandler) ServeHTTP(w http.ResponseWriter, r *http.Request)
 	h.originHandler.ServeHTTP(w, r.WithContext(ctx))
 }
 
-type ResTodoList struct {
-	List []*Todo `json:"list,omitempty"`
-}
-
 // 获取待办列表
 func TodoList(w http.ResponseWriter, r *http.Request) {
 	ctxval := getContextValue(r)
 
-	_ = go_sdk.NewResponse().UpdateThisMessage(
-		ctxval.QueryParameter,
-		"待办列表",
-		ResTodoList{
-			List: todoStore.ListTodo(ctxval.Member.OpenID),
-		}).
-		Output(w)
+	data := TodoListData{}
+	for _, todo := range todoStore.ListTodo(ctxval.Member.OpenID) {
+		data.List = append(data.List, TodoListDataListItem{
+			ID:    todo.ID,
+			Title: todo.Title,
+			Done:  todo.Done,
+		})
+	}
+
+	_ = go_sdk.UpdateThisMessageT(go_sdk.NewResponse(), ctxval.QueryParameter, "待办列表", data).Output(w)
 }
 
 func AddTodo(w http.ResponseWriter, r *http.Request) {
@@ -158,7 +164,7 @@ func TodoDone(w http.ResponseWriter, r *http.Request) {
 	todoStore.DeleteTodo(id, ctxval.Member.OpenID)
 
 	ops := go_sdk.NewUpdatePart()
-	ops.AddOpRemove(go_sdk.NewRemove("list", []int{index}))
+	ops.AddOpRemove(go_sdk.NewRemoveOf(TodoListFieldList, index))
 
 	_ = go_sdk.NewResponse().UpdatePartData(ops).Output(w)
 }
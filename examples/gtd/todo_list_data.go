@@ -0,0 +1,69 @@
+// Code generated by smgen from todo-list.json. DO NOT EDIT.
+
+package main
+
+import (
+	"strconv"
+
+	go_sdk "github.com/super-message/go-sdk"
+)
+
+// todo-list 模板的名称、ID 和版本号
+const (
+	TodoListTemplateName    = "todo-list"
+	TodoListTemplateID      = "5e9f0c7a8d1b2c3d4e5f6a7b"
+	TodoListTemplateVersion = 1
+)
+
+// TodoListData 是 todo-list 模板的数据结构
+type TodoListData struct {
+	List []TodoListDataListItem `json:"list"`
+}
+
+type TodoListDataListItem struct {
+	Done  bool   `json:"done"`
+	ID    int    `json:"id"`
+	Title string `json:"title"`
+}
+
+// todo-list 模板数据的 keypath，用于 UpdatePart 中的各种操作
+const (
+	TodoListKeyList = "list"
+)
+
+func TodoListKeyListItem(i int) string {
+	return "list." + strconv.Itoa(i)
+}
+
+func TodoListKeyListDone(i int) string {
+	return "list." + strconv.Itoa(i) + ".done"
+}
+
+func TodoListKeyListID(i int) string {
+	return "list." + strconv.Itoa(i) + ".id"
+}
+
+func TodoListKeyListTitle(i int) string {
+	return "list." + strconv.Itoa(i) + ".title"
+}
+
+// todo-list 模板数据的字段，用于 go_sdk.NewSetOf 等类型安全的 UpdatePart 操作
+var (
+	TodoListFieldList = go_sdk.NewField[TodoListData, []TodoListDataListItem](TodoListKeyList)
+)
+
+func TodoListFieldListItem(i int) go_sdk.Field[TodoListData, TodoListDataListItem] {
+	return go_sdk.NewField[TodoListData, TodoListDataListItem](TodoListKeyListItem(i))
+}
+
+func TodoListFieldListDone(i int) go_sdk.Field[TodoListData, bool] {
+	return go_sdk.NewField[TodoListData, bool](TodoListKeyListDone(i))
+}
+
+func TodoListFieldListID(i int) go_sdk.Field[TodoListData, int] {
+	return go_sdk.NewField[TodoListData, int](TodoListKeyListID(i))
+}
+
+func TodoListFieldListTitle(i int) go_sdk.Field[TodoListData, string] {
+	return go_sdk.NewField[TodoListData, string](TodoListKeyListTitle(i))
+}
@@ -0,0 +1,138 @@
+package go_sdk
+
+import (
+	"net/url"
+	"sync"
+)
+
+// Logger 定义了 SDK 输出结构化日志的接口，args 为交替出现的键和值，用法与 log/slog 一致，
+// *slog.Logger 可以直接使用：
+//      go_sdk.SetLogger(slog.Default())
+// zap 的 *zap.SugaredLogger 只需要简单适配：
+//      type zapLogger struct{ *zap.SugaredLogger }
+//
+//      func (l zapLogger) Debug(msg string, args ...interface{}) { l.Debugw(msg, args...) }
+//      func (l zapLogger) Info(msg string, args ...interface{})  { l.Infow(msg, args...) }
+//      func (l zapLogger) Warn(msg string, args ...interface{})  { l.Warnw(msg, args...) }
+//      func (l zapLogger) Error(msg string, args ...interface{}) { l.Errorw(msg, args...) }
+// logrus 等以 map 作为字段的日志库可以使用 LoggerFunc 适配
+type Logger interface {
+	Debug(msg string, args ...interface{})
+	Info(msg string, args ...interface{})
+	Warn(msg string, args ...interface{})
+	Error(msg string, args ...interface{})
+}
+
+// LogLevel 表示日志级别
+type LogLevel int
+
+const (
+	LevelDebug LogLevel = iota
+	LevelInfo
+	LevelWarn
+	LevelError
+)
+
+func (l LogLevel) String() string {
+	switch l {
+	case LevelDebug:
+		return "debug"
+	case LevelInfo:
+		return "info"
+	case LevelWarn:
+		return "warn"
+	case LevelError:
+		return "error"
+	}
+	return "unknown"
+}
+
+// LoggerFunc 将一个函数适配为 Logger，fields 为日志的键值对，比如接入 logrus：
+//      go_sdk.SetLogger(go_sdk.LoggerFunc(func(level go_sdk.LogLevel, msg string, fields map[string]interface{}) {
+//          lvl, _ := logrus.ParseLevel(level.String())
+//          logrus.WithFields(fields).Log(lvl, msg)
+//      }))
+type LoggerFunc func(level LogLevel, msg string, fields map[string]interface{})
+
+func (f LoggerFunc) log(level LogLevel, msg string, args []interface{}) {
+	fields := make(map[string]interface{}, len(args)/2)
+	for i := 0; i+1 < len(args); i += 2 {
+		if key, ok := args[i].(string); ok {
+			fields[key] = args[i+1]
+		}
+	}
+	f(level, msg, fields)
+}
+
+func (f LoggerFunc) Debug(msg string, args ...interface{}) { f.log(LevelDebug, msg, args) }
+func (f LoggerFunc) Info(msg string, args ...interface{})  { f.log(LevelInfo, msg, args) }
+func (f LoggerFunc) Warn(msg string, args ...interface{})  { f.log(LevelWarn, msg, args) }
+func (f LoggerFunc) Error(msg string, args ...interface{}) { f.log(LevelError, msg, args) }
+
+type nopLogger struct{}
+
+func (nopLogger) Debug(msg string, args ...interface{}) {}
+func (nopLogger) Info(msg string, args ...interface{})  {}
+func (nopLogger) Warn(msg string, args ...interface{})  {}
+func (nopLogger) Error(msg string, args ...interface{}) {}
+
+var (
+	loggerMutex   sync.RWMutex
+	defaultLogger Logger = nopLogger{}
+)
+
+// SetLogger 设置 SDK 默认的 Logger，用于 Response.Output 等服务端辅助方法，以及没有通过 WithLogger
+// 单独设置 Logger 的 Client。默认不输出任何日志
+func SetLogger(l Logger) {
+	if l == nil {
+		l = nopLogger{}
+	}
+
+	loggerMutex.Lock()
+	defaultLogger = l
+	loggerMutex.Unlock()
+}
+
+func getLogger() Logger {
+	loggerMutex.RLock()
+	defer loggerMutex.RUnlock()
+
+	return defaultLogger
+}
+
+// WithLogger 设置 Client 使用的 Logger，每次调用平台接口都会输出一条日志，包括请求方法、路径、
+// HTTP 状态码、耗时以及错误码，日志中的 token 都经过脱敏处理
+func WithLogger(l Logger) ClientOption {
+	return func(c *Client) {
+		c.logger = l
+	}
+}
+
+func (c *Client) log() Logger {
+	if c.logger != nil {
+		return c.logger
+	}
+	return getLogger()
+}
+
+// redact 对 token 进行脱敏，只保留前 4 个字符
+func redact(token string) string {
+	if len(token) <= 4 {
+		return "****"
+	}
+	return token[:4] + "****"
+}
+
+// redactQuery 对 url query 中的 token 进行脱敏
+func redactQuery(query url.Values) string {
+	redacted := make(url.Values, len(query))
+	for k, vs := range query {
+		for _, v := range vs {
+			if k == "token" || k == "accessToken" {
+				v = redact(v)
+			}
+			redacted.Add(k, v)
+		}
+	}
+	return redacted.Encode()
+}
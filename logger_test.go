@@ -0,0 +1,160 @@
+package go_sdk
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+type logEntry struct {
+	level  LogLevel
+	msg    string
+	fields map[string]interface{}
+}
+
+type recordLogger struct {
+	mutex   sync.Mutex
+	entries []logEntry
+}
+
+func (l *recordLogger) Logger() Logger {
+	return LoggerFunc(func(level LogLevel, msg string, fields map[string]interface{}) {
+		l.mutex.Lock()
+		l.entries = append(l.entries, logEntry{level: level, msg: msg, fields: fields})
+		l.mutex.Unlock()
+	})
+}
+
+func (l *recordLogger) Entries() []logEntry {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	return append([]logEntry(nil), l.entries...)
+}
+
+func TestRedactQuery(t *testing.T) {
+	Convey("Tokens in query are masked", t, func() {
+		query := url.Values{
+			"token":       {"request-token"},
+			"accessToken": {"access-token"},
+			"page":        {"2"},
+		}
+
+		redacted, err := url.ParseQuery(redactQuery(query))
+		So(err, ShouldBeNil)
+		So(redacted.Get("token"), ShouldEqual, "requ****")
+		So(redacted.Get("accessToken"), ShouldEqual, "acce****")
+		So(redacted.Get("page"), ShouldEqual, "2")
+		So(redact("abc"), ShouldEqual, "****")
+	})
+}
+
+func TestLoggerFunc(t *testing.T) {
+	Convey("Args are mapped to fields", t, func() {
+		rl := &recordLogger{}
+		l := rl.Logger()
+
+		l.Info("hello", "a", 1, "b", "two")
+		l.Warn("odd", "a", 1, "dangling")
+		l.Error("bad key", 1, "x", "c", true)
+		l.Debug("empty")
+
+		entries := rl.Entries()
+		So(entries, ShouldHaveLength, 4)
+		So(entries[0].level, ShouldEqual, LevelInfo)
+		So(entries[0].msg, ShouldEqual, "hello")
+		So(entries[0].fields, ShouldResemble, map[string]interface{}{"a": 1, "b": "two"})
+		So(entries[1].level, ShouldEqual, LevelWarn)
+		So(entries[1].fields, ShouldResemble, map[string]interface{}{"a": 1})
+		So(entries[2].level, ShouldEqual, LevelError)
+		So(entries[2].fields, ShouldResemble, map[string]interface{}{"c": true})
+		So(entries[3].level, ShouldEqual, LevelDebug)
+		So(entries[3].fields, ShouldBeEmpty)
+	})
+}
+
+func TestLogRequest(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("token") == "bad-request-token" {
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{"code": 10001, "message": "invalid token"})
+			return
+		}
+
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"code": 0,
+			"data": Member{OpenID: "u1", ExpiredAt: time.Now().Unix() + 3600},
+		})
+	}))
+	defer server.Close()
+
+	originHost := apiHost
+	apiHost = server.URL
+	defer func() { apiHost = originHost }()
+
+	Convey("Platform requests are logged without tokens", t, func() {
+		rl := &recordLogger{}
+		client := NewClient("secret-access-token", nil, WithLogger(rl.Logger()))
+
+		_, err := client.VerifyRequestToken("good-request-token")
+		So(err, ShouldBeNil)
+		_, err = client.VerifyRequestToken("bad-request-token")
+		So(err, ShouldNotBeNil)
+
+		entries := rl.Entries()
+		So(entries, ShouldHaveLength, 2)
+		So(entries[0].level, ShouldEqual, LevelDebug)
+		So(entries[0].msg, ShouldEqual, "platform api request")
+		So(entries[0].fields["method"], ShouldEqual, http.MethodGet)
+		So(entries[0].fields["status"], ShouldEqual, http.StatusOK)
+		So(entries[0].fields["accessToken"], ShouldEqual, "secr****")
+		So(entries[1].level, ShouldEqual, LevelWarn)
+		So(entries[1].fields["code"], ShouldEqual, 10001)
+		So(entries[1].fields["error"], ShouldEqual, "invalid token")
+
+		for _, e := range entries {
+			for _, v := range e.fields {
+				s := fmt.Sprint(v)
+				So(s, ShouldNotContainSubstring, "secret-access-token")
+				So(s, ShouldNotContainSubstring, "request-token")
+			}
+		}
+	})
+
+	Convey("Errors are logged at error level", t, func() {
+		rl := &recordLogger{}
+		client := NewClient("secret-access-token", nil, WithLogger(rl.Logger()))
+
+		client.logRequest(http.MethodPost, "/messages", "", "secret-access-token", 0, time.Millisecond, errors.New("connection refused"))
+		entries := rl.Entries()
+		So(entries, ShouldHaveLength, 1)
+		So(entries[0].level, ShouldEqual, LevelError)
+		So(entries[0].fields["error"], ShouldEqual, "connection refused")
+		So(entries[0].fields["accessToken"], ShouldEqual, "secr****")
+	})
+}
+
+func TestResponseOutputLog(t *testing.T) {
+	Convey("Response output is logged with its kinds", t, func() {
+		rl := &recordLogger{}
+		SetLogger(rl.Logger())
+		defer SetLogger(nil)
+
+		w := httptest.NewRecorder()
+		So(ShowSuccess(w, "done"), ShouldBeNil)
+
+		entries := rl.Entries()
+		So(entries, ShouldHaveLength, 1)
+		So(entries[0].level, ShouldEqual, LevelDebug)
+		So(entries[0].msg, ShouldEqual, "response written")
+		So(entries[0].fields["kinds"], ShouldResemble, []string{"dismiss"})
+		So(entries[0].fields["tip"], ShouldEqual, "done")
+	})
+}
@@ -0,0 +1,314 @@
+package go_sdk
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"hash/fnv"
+	"net/http"
+	"sync"
+	"time"
+)
+
+var (
+	ErrMessageKeyRequired = errors.New("message key is required")
+	ErrMessageNotTracked  = errors.New("message is not tracked")
+)
+
+// TrackedMessage 为 MessageStore 中记录的一条已推送消息，Key 为业务对象的标识，比如 "order:1024"，
+// MessageContentRequest 为最近一次推送或更新后的模板、标题和数据快照
+type TrackedMessage struct {
+	Key        string   `json:"key"`
+	MessageID  int64    `json:"messageID"`
+	Recipients []string `json:"recipients,omitempty"`
+	ToAll      bool     `json:"toAll,omitempty"`
+	MessageContentRequest
+
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// MessageStore 定义了一套用来保存业务对象与消息对应关系的接口，SDK 实现了基于内存和 SQL 数据库的存储
+type MessageStore interface {
+	// Get 返回 key 对应的记录，不存在时返回 nil, nil
+	Get(key string) (*TrackedMessage, error)
+	// Put 保存新的记录，或者覆盖已有的记录
+	Put(m *TrackedMessage) error
+	// Delete 删除 key 对应的记录，不存在时不返回错误
+	Delete(key string) error
+}
+
+// MessageTracker 通过 MessageStore 记录每个业务对象对应的消息，调用方只需要使用业务对象的标识，
+// 不需要自己维护消息 ID：
+//      tracker := NewMessageTracker(client, NewSQLMessageStore(db, "sm_messages"))
+//      // 第一次调用时推送消息，之后更新同一条消息
+//      _, err := tracker.UpsertMessageFor("order:1024", cmr)
+//      // 订单完成后删除消息
+//      err = tracker.DeleteMessageFor("order:1024")
+// 同一个 MessageTracker 对同一个 key 的操作是串行的，多个实例同时操作同一个 key 时可能重复推送
+type MessageTracker struct {
+	client *Client
+	store  MessageStore
+	locks  [64]sync.Mutex
+}
+
+func NewMessageTracker(client *Client, store MessageStore) *MessageTracker {
+	return &MessageTracker{client: client, store: store}
+}
+
+func (t *MessageTracker) lock(key string) *sync.Mutex {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(key))
+	m := &t.locks[h.Sum32()%uint32(len(t.locks))]
+	m.Lock()
+	return m
+}
+
+// MessageFor 返回 key 对应的消息记录，没有记录时返回 ErrMessageNotTracked
+func (t *MessageTracker) MessageFor(key string) (*TrackedMessage, error) {
+	m, err := t.store.Get(key)
+	if err != nil {
+		return nil, err
+	}
+	if m == nil {
+		return nil, ErrMessageNotTracked
+	}
+	return m, nil
+}
+
+// UpsertMessageFor 在 key 还没有对应的消息时推送消息，否则用 cmr 的模板、标题和数据更新已有的消息，
+// 此时 cmr 的接收人被忽略。已有的消息在平台上不存在（更新时返回 HTTP 404 或者错误码 404）时删除记录并重新推送。
+// 返回消息 ID 以及是否为新推送的消息
+func (t *MessageTracker) UpsertMessageFor(key string, cmr *CreateMessageRequest) (messageID int64, created bool, err error) {
+	return t.UpsertMessageForContext(context.Background(), key, cmr)
+}
+
+// UpsertMessageForContext 与 UpsertMessageFor 相同，ctx 用于控制请求的取消和超时
+func (t *MessageTracker) UpsertMessageForContext(ctx context.Context, key string, cmr *CreateMessageRequest) (messageID int64, created bool, err error) {
+	if key == "" {
+		return 0, false, ErrMessageKeyRequired
+	}
+	defer t.lock(key).Unlock()
+
+	m, err := t.store.Get(key)
+	if err != nil {
+		return
+	}
+	if m == nil {
+		return t.create(ctx, key, cmr)
+	}
+
+	err = t.client.UpdateMessageContext(ctx, &UpdateMessageRequest{ID: m.MessageID, MessageContentRequest: cmr.MessageContentRequest})
+	if isNotFoundError(err) {
+		// 消息已经被删除（比如用户在 App 中删除或者已过期），记录失效
+		if err = t.store.Delete(key); err != nil {
+			return
+		}
+		return t.create(ctx, key, cmr)
+	}
+	if err != nil {
+		return
+	}
+
+	m.MessageContentRequest = cmr.MessageContentRequest
+	m.UpdatedAt = time.Now()
+	return m.MessageID, false, t.store.Put(m)
+}
+
+// isNotFoundError 判断平台是否返回了消息不存在，平台可能返回 HTTP 404，也可能在响应中返回错误码 404
+func isNotFoundError(err error) bool {
+	switch e := err.(type) {
+	case *StatusError:
+		return e.StatusCode == http.StatusNotFound
+	case *APIError:
+		return e.Code == http.StatusNotFound
+	}
+	return false
+}
+
+// create 推送消息并保存 key 对应的记录，调用时需要持有 key 的锁
+func (t *MessageTracker) create(ctx context.Context, key string, cmr *CreateMessageRequest) (int64, bool, error) {
+	messageID, err := t.client.CreateMessageContext(ctx, cmr)
+	if err != nil {
+		return 0, false, err
+	}
+
+	now := time.Now()
+	m := &TrackedMessage{
+		Key:                   key,
+		MessageID:             messageID,
+		Recipients:            cmr.Recipients,
+		ToAll:                 cmr.ToAll,
+		MessageContentRequest: cmr.MessageContentRequest,
+		CreatedAt:             now,
+		UpdatedAt:             now,
+	}
+	return messageID, true, t.store.Put(m)
+}
+
+// PatchMessageFor 对 key 对应的消息执行局部更新，并将 ops 应用到保存的数据快照上。
+// 如果 ops 无法应用到快照上，则返回错误并且不会请求平台
+func (t *MessageTracker) PatchMessageFor(key string, ops *UpdatePart) error {
+	return t.PatchMessageForContext(context.Background(), key, ops)
+}
+
+// PatchMessageForContext 与 PatchMessageFor 相同，ctx 用于控制请求的取消和超时
+func (t *MessageTracker) PatchMessageForContext(ctx context.Context, key string, ops *UpdatePart) error {
+	if key == "" {
+		return ErrMessageKeyRequired
+	}
+	defer t.lock(key).Unlock()
+
+	m, err := t.store.Get(key)
+	if err != nil {
+		return err
+	}
+	if m == nil {
+		return ErrMessageNotTracked
+	}
+
+	// 在副本上应用，避免平台请求失败时快照已被修改
+	v, err := normalize(m.Data)
+	if err != nil {
+		return err
+	}
+	data, _ := v.(map[string]interface{})
+	if data == nil {
+		data = make(map[string]interface{})
+	}
+	if ops != nil {
+		if err = ops.Apply(data); err != nil {
+			return err
+		}
+	}
+
+	if err = t.client.PatchMessageContext(ctx, m.MessageID, ops); err != nil {
+		return err
+	}
+
+	m.Data = data
+	m.UpdatedAt = time.Now()
+	return t.store.Put(m)
+}
+
+// DeleteMessageFor 删除 key 对应的消息以及记录，没有记录时直接返回
+func (t *MessageTracker) DeleteMessageFor(key string) error {
+	return t.DeleteMessageForContext(context.Background(), key)
+}
+
+// DeleteMessageForContext 与 DeleteMessageFor 相同，ctx 用于控制请求的取消和超时
+func (t *MessageTracker) DeleteMessageForContext(ctx context.Context, key string) error {
+	if key == "" {
+		return ErrMessageKeyRequired
+	}
+	defer t.lock(key).Unlock()
+
+	m, err := t.store.Get(key)
+	if err != nil || m == nil {
+		return err
+	}
+
+	if err = t.client.DeleteMessageContext(ctx, m.MessageID); err != nil {
+		return err
+	}
+	return t.store.Delete(key)
+}
+
+// MemoryMessageStore 实现了基于内存的 MessageStore 接口，服务重启后记录将丢失，适用于开发和测试
+type MemoryMessageStore struct {
+	mutex    sync.RWMutex
+	messages map[string]*TrackedMessage
+}
+
+func NewMemoryMessageStore() *MemoryMessageStore {
+	return &MemoryMessageStore{
+		messages: make(map[string]*TrackedMessage),
+	}
+}
+
+func (s *MemoryMessageStore) Get(key string) (*TrackedMessage, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	m, ok := s.messages[key]
+	if !ok {
+		return nil, nil
+	}
+	c := *m
+	return &c, nil
+}
+
+func (s *MemoryMessageStore) Put(m *TrackedMessage) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	c := *m
+	s.messages[m.Key] = &c
+	return nil
+}
+
+func (s *MemoryMessageStore) Delete(key string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	delete(s.messages, key)
+	return nil
+}
+
+// SQLMessageStore 实现了基于 SQL 数据库的 MessageStore 接口，可以在多个实例间共享，表结构如下（以 MySQL 为例）：
+//      CREATE TABLE sm_messages (
+//          msg_key    VARCHAR(191) NOT NULL PRIMARY KEY,
+//          message_id BIGINT       NOT NULL,
+//          payload    TEXT         NOT NULL,
+//          updated_at BIGINT       NOT NULL
+//      );
+// updated_at 保存为毫秒级的 UNIX 时间戳。占位符的设置与 SQLOutboxStore 相同
+type SQLMessageStore struct {
+	DB                *sql.DB
+	Table             string
+	DollarPlaceholder bool
+}
+
+func NewSQLMessageStore(db *sql.DB, table string) *SQLMessageStore {
+	return &SQLMessageStore{DB: db, Table: table}
+}
+
+func (s *SQLMessageStore) query(q string) string {
+	return sqlQuery(q, s.Table, s.DollarPlaceholder)
+}
+
+func (s *SQLMessageStore) Get(key string) (*TrackedMessage, error) {
+	var payload string
+	err := s.DB.QueryRow(s.query("SELECT payload FROM {table} WHERE msg_key = ?"), key).Scan(&payload)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	m := &TrackedMessage{}
+	if err = json.Unmarshal([]byte(payload), m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (s *SQLMessageStore) Put(m *TrackedMessage) error {
+	payload, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+
+	return sqlUpsert(s.DB, s.query("SELECT COUNT(*) FROM {table} WHERE msg_key = ?"), m.Key,
+		s.query("UPDATE {table} SET message_id = ?, payload = ?, updated_at = ? WHERE msg_key = ?"),
+		[]interface{}{m.MessageID, string(payload), toMillis(m.UpdatedAt), m.Key},
+		s.query("INSERT INTO {table} (msg_key, message_id, payload, updated_at) VALUES (?, ?, ?, ?)"),
+		[]interface{}{m.Key, m.MessageID, string(payload), toMillis(m.UpdatedAt)})
+}
+
+func (s *SQLMessageStore) Delete(key string) error {
+	_, err := s.DB.Exec(s.query("DELETE FROM {table} WHERE msg_key = ?"), key)
+	return err
+}
@@ -0,0 +1,162 @@
+package go_sdk
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestMessageTracker(t *testing.T) {
+	var methods []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		methods = append(methods, r.Method)
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"code": 0, "data": map[string]interface{}{"id": 7}})
+	}))
+	defer server.Close()
+
+	Convey("Create, update, patch and delete by business key", t, func() {
+		methods = nil
+		client := NewClient("token", nil, WithAPIHost(server.URL))
+		store := NewMemoryMessageStore()
+		tracker := NewMessageTracker(client, store)
+
+		cmr := &CreateMessageRequest{
+			Recipients: []string{"u1"},
+			MessageContentRequest: MessageContentRequest{
+				TemplateID:      "t1",
+				TemplateVersion: 1,
+				Title:           "order",
+				Data:            map[string]interface{}{"status": "paid"},
+			},
+		}
+		id, created, err := tracker.UpsertMessageFor("order:1", cmr)
+		So(err, ShouldBeNil)
+		So(created, ShouldBeTrue)
+		So(id, ShouldEqual, 7)
+
+		cmr.Data = map[string]interface{}{"status": "packed", "items": []interface{}{"a"}}
+		id, created, err = tracker.UpsertMessageFor("order:1", cmr)
+		So(err, ShouldBeNil)
+		So(created, ShouldBeFalse)
+		So(id, ShouldEqual, 7)
+
+		ops := NewUpdatePart()
+		ops.AddOpSet(NewSet().Add("status", "shipped"))
+		So(tracker.PatchMessageFor("order:1", ops), ShouldBeNil)
+
+		m, err := tracker.MessageFor("order:1")
+		So(err, ShouldBeNil)
+		So(m.Recipients, ShouldResemble, []string{"u1"})
+		So(m.Data["status"], ShouldEqual, "shipped")
+		So(m.Data["items"], ShouldResemble, []interface{}{"a"})
+
+		So(tracker.DeleteMessageFor("order:1"), ShouldBeNil)
+		_, err = tracker.MessageFor("order:1")
+		So(err, ShouldEqual, ErrMessageNotTracked)
+		So(tracker.DeleteMessageFor("order:1"), ShouldBeNil)
+		So(tracker.PatchMessageFor("order:1", ops), ShouldEqual, ErrMessageNotTracked)
+
+		So(methods, ShouldResemble, []string{"POST", "PUT", "PATCH", "DELETE"})
+	})
+}
+
+func TestMessageTrackerRecreate(t *testing.T) {
+	var methods []string
+	apiNotFound := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		methods = append(methods, r.Method)
+		if r.Method == http.MethodPut && apiNotFound {
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{"code": 404, "message": "message not found"})
+			return
+		}
+		if r.Method == http.MethodPut {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"code": 0, "data": map[string]interface{}{"id": 8}})
+	}))
+	defer server.Close()
+
+	Convey("Messages deleted on the platform are pushed again", t, func() {
+		client := NewClient("token", nil, WithAPIHost(server.URL))
+		store := NewMemoryMessageStore()
+		tracker := NewMessageTracker(client, store)
+		_ = store.Put(&TrackedMessage{Key: "order:1", MessageID: 7, Recipients: []string{"u1"}})
+
+		cmr := &CreateMessageRequest{
+			Recipients: []string{"u2"},
+			MessageContentRequest: MessageContentRequest{
+				TemplateID:      "t1",
+				TemplateVersion: 1,
+				Title:           "order",
+				Data:            map[string]interface{}{"status": "paid"},
+			},
+		}
+		id, created, err := tracker.UpsertMessageFor("order:1", cmr)
+		So(err, ShouldBeNil)
+		So(created, ShouldBeTrue)
+		So(id, ShouldEqual, 8)
+		So(methods, ShouldResemble, []string{"PUT", "POST"})
+
+		m, err := tracker.MessageFor("order:1")
+		So(err, ShouldBeNil)
+		So(m.MessageID, ShouldEqual, 8)
+		So(m.Recipients, ShouldResemble, []string{"u2"})
+
+		// 平台也可能以错误码 404 表示消息不存在
+		apiNotFound = true
+		methods = nil
+		_ = store.Put(&TrackedMessage{Key: "order:2", MessageID: 7, Recipients: []string{"u1"}})
+		_, created, err = tracker.UpsertMessageFor("order:2", cmr)
+		So(err, ShouldBeNil)
+		So(created, ShouldBeTrue)
+		So(methods, ShouldResemble, []string{"PUT", "POST"})
+	})
+}
+
+func TestSQLMessageStore(t *testing.T) {
+	Convey("Get, upsert and delete tracked messages", t, func() {
+		db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		So(err, ShouldBeNil)
+		defer db.Close()
+
+		store := NewSQLMessageStore(db, "sm_messages")
+		m := &TrackedMessage{Key: "order:1", MessageID: 7, UpdatedAt: time.Unix(100, 0)}
+
+		get := "SELECT payload FROM sm_messages WHERE msg_key = ?"
+		count := "SELECT COUNT(*) FROM sm_messages WHERE msg_key = ?"
+		insert := "INSERT INTO sm_messages (msg_key, message_id, payload, updated_at) VALUES (?, ?, ?, ?)"
+		update := "UPDATE sm_messages SET message_id = ?, payload = ?, updated_at = ? WHERE msg_key = ?"
+
+		mock.ExpectQuery(get).WithArgs("order:1").WillReturnError(sql.ErrNoRows)
+		found, err := store.Get("order:1")
+		So(err, ShouldBeNil)
+		So(found, ShouldBeNil)
+
+		mock.ExpectQuery(count).WithArgs("order:1").WillReturnRows(sqlmock.NewRows([]string{"n"}).AddRow(0))
+		mock.ExpectExec(insert).WithArgs("order:1", int64(7), sqlmock.AnyArg(), int64(100000)).WillReturnResult(sqlmock.NewResult(1, 1))
+		So(store.Put(m), ShouldBeNil)
+
+		// 数据没有变化时 MySQL 的 RowsAffected 为 0，不能因此再插入
+		mock.ExpectQuery(count).WithArgs("order:1").WillReturnRows(sqlmock.NewRows([]string{"n"}).AddRow(1))
+		mock.ExpectExec(update).WithArgs(int64(7), sqlmock.AnyArg(), int64(100000), "order:1").WillReturnResult(sqlmock.NewResult(0, 0))
+		So(store.Put(m), ShouldBeNil)
+
+		payload, _ := json.Marshal(m)
+		mock.ExpectQuery(get).WithArgs("order:1").WillReturnRows(sqlmock.NewRows([]string{"payload"}).AddRow(string(payload)))
+		found, err = store.Get("order:1")
+		So(err, ShouldBeNil)
+		So(found.MessageID, ShouldEqual, 7)
+
+		mock.ExpectExec("DELETE FROM sm_messages WHERE msg_key = ?").WithArgs("order:1").WillReturnResult(sqlmock.NewResult(0, 1))
+		So(store.Delete("order:1"), ShouldBeNil)
+
+		So(mock.ExpectationsWereMet(), ShouldBeNil)
+	})
+}
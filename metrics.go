@@ -0,0 +1,62 @@
+package go_sdk
+
+import (
+	"sync"
+	"time"
+)
+
+// Metrics 定义了 SDK 上报监控指标的接口，不依赖任何监控系统，可以自行对接 StatsD、OpenTelemetry 等，
+// github.com/super-message/go-sdk/smprometheus 包提供了基于 Prometheus 的实现
+type Metrics interface {
+	// ObserveRequest 记录一次平台接口调用，status 为 HTTP 状态码（请求未发出时为 0），code 为 APIError 的错误码
+	ObserveRequest(endpoint Endpoint, method string, status, code int, latency time.Duration)
+	// ObserveCache 记录一次 request token 缓存的查询结果
+	ObserveCache(hit bool)
+	// ObserveResponse 记录一次 Response.Output 的输出，kinds 为响应中包含的操作：delete、update、updatePart、new、dismiss
+	ObserveResponse(kinds []string)
+}
+
+type nopMetrics struct{}
+
+func (nopMetrics) ObserveRequest(endpoint Endpoint, method string, status, code int, latency time.Duration) {
+}
+func (nopMetrics) ObserveCache(hit bool)          {}
+func (nopMetrics) ObserveResponse(kinds []string) {}
+
+var (
+	metricsMutex   sync.RWMutex
+	defaultMetrics Metrics = nopMetrics{}
+)
+
+// SetMetrics 设置 SDK 默认的 Metrics，用于 Response.Output 等服务端辅助方法，以及没有通过 WithMetrics
+// 单独设置 Metrics 的 Client
+func SetMetrics(m Metrics) {
+	if m == nil {
+		m = nopMetrics{}
+	}
+
+	metricsMutex.Lock()
+	defaultMetrics = m
+	metricsMutex.Unlock()
+}
+
+func getMetrics() Metrics {
+	metricsMutex.RLock()
+	defer metricsMutex.RUnlock()
+
+	return defaultMetrics
+}
+
+// WithMetrics 设置 Client 使用的 Metrics
+func WithMetrics(m Metrics) ClientOption {
+	return func(c *Client) {
+		c.metrics = m
+	}
+}
+
+func (c *Client) observer() Metrics {
+	if c.metrics != nil {
+		return c.metrics
+	}
+	return getMetrics()
+}
@@ -1,9 +1,17 @@
 package go_sdk
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
 	"reflect"
+	"sort"
+	"strconv"
+	"strings"
 )
 
+var ErrNilData = errors.New("can not apply operations to nil data")
+
 type UpdatePart struct {
 	IgnoreError    bool        `json:"ignoreError,omitempty"`
 	NoMoreContents bool        `json:"noMoreContents,omitempty"`
@@ -56,6 +64,24 @@ func (ops *UpdatePart) MarkNoMoreContents() {
 	ops.NoMoreContents = true
 }
 
+// Apply 按顺序将 UpdatePart 中的操作应用到 data 上，可用于在服务端维护消息数据的副本，或者在本地模拟客户端。
+// 处理方式参照 App 客户端：set 时创建不存在的对象，insert 的 index 为 -1 时追加到末尾，unset 数组元素时置为 null，
+// 下标越界等边界情况与客户端的表现不一定相同。
+// data 应为 JSON 解码得到的数据（map[string]interface{}、[]interface{} 等），操作中的值会先转换为同样的形式，
+// data 为 nil 时返回 ErrNilData。IgnoreError 为 true 时跳过出错的操作，否则返回第一个错误，此时出错之前的操作已经生效
+func (ops *UpdatePart) Apply(data map[string]interface{}) error {
+	if data == nil {
+		return ErrNilData
+	}
+
+	for _, op := range ops.Ops {
+		if err := op.apply(data); err != nil && !ops.IgnoreError {
+			return err
+		}
+	}
+	return nil
+}
+
 type Operation struct {
 	Set    *Set    `json:"$set,omitempty"`
 	Unset  *Unset  `json:"$unset,omitempty"`
@@ -145,3 +171,212 @@ func NewRemove(keyPath string, indexes []int) *Remove {
 		Indexes: indexes,
 	}
 }
+
+func (op Operation) apply(data map[string]interface{}) error {
+	switch {
+	case op.Set != nil:
+		keyPaths := make([]string, 0, len(*op.Set))
+		for keyPath := range *op.Set {
+			keyPaths = append(keyPaths, keyPath)
+		}
+		sort.Strings(keyPaths)
+
+		for _, keyPath := range keyPaths {
+			value, err := normalize((*op.Set)[keyPath])
+			if err != nil {
+				return err
+			}
+			if err = setKeyPath(data, keyPath, value); err != nil {
+				return err
+			}
+		}
+	case op.Unset != nil:
+		for _, keyPath := range *op.Unset {
+			if err := unsetKeyPath(data, keyPath); err != nil {
+				return err
+			}
+		}
+	case op.Insert != nil:
+		return op.Insert.apply(data)
+	case op.Remove != nil:
+		return op.Remove.apply(data)
+	}
+	return nil
+}
+
+func (op *Insert) apply(data map[string]interface{}) error {
+	list, err := listAt(data, op.KeyPath)
+	if err != nil {
+		return err
+	}
+
+	index := op.Index
+	if index < 0 {
+		index = len(list)
+	}
+	if index > len(list) {
+		return fmt.Errorf("keypath %s: index %d out of range", op.KeyPath, op.Index)
+	}
+	if len(op.Ele) == 0 {
+		return nil
+	}
+
+	ele, err := normalize(op.Ele)
+	if err != nil {
+		return err
+	}
+
+	inserted := make([]interface{}, 0, len(list)+len(op.Ele))
+	inserted = append(inserted, list[:index]...)
+	inserted = append(inserted, ele.([]interface{})...)
+	inserted = append(inserted, list[index:]...)
+	return setKeyPath(data, op.KeyPath, inserted)
+}
+
+func (op *Remove) apply(data map[string]interface{}) error {
+	list, err := listAt(data, op.KeyPath)
+	if err != nil {
+		return err
+	}
+
+	removed := make(map[int]bool, len(op.Indexes))
+	for _, i := range op.Indexes {
+		if i < 0 || i >= len(list) {
+			return fmt.Errorf("keypath %s: index %d out of range", op.KeyPath, i)
+		}
+		removed[i] = true
+	}
+
+	rest := make([]interface{}, 0, len(list))
+	for i, v := range list {
+		if !removed[i] {
+			rest = append(rest, v)
+		}
+	}
+	return setKeyPath(data, op.KeyPath, rest)
+}
+
+// normalize 将值转换为 JSON 解码后的形式，比如结构体转换为 map[string]interface{}
+func normalize(v interface{}) (interface{}, error) {
+	switch v.(type) {
+	case nil, string, bool, float64, json.Number:
+		return v, nil
+	}
+
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	var normalized interface{}
+	err = json.Unmarshal(b, &normalized)
+	return normalized, err
+}
+
+// lookupKeyPath 返回 keypath 对应的值，keypath 以 . 分隔，数组元素使用下标，比如 list.3.done
+func lookupKeyPath(data map[string]interface{}, keyPath string) (interface{}, bool) {
+	var cur interface{} = data
+	for _, key := range strings.Split(keyPath, ".") {
+		switch c := cur.(type) {
+		case map[string]interface{}:
+			v, ok := c[key]
+			if !ok {
+				return nil, false
+			}
+			cur = v
+		case []interface{}:
+			i, err := strconv.Atoi(key)
+			if err != nil || i < 0 || i >= len(c) {
+				return nil, false
+			}
+			cur = c[i]
+		default:
+			return nil, false
+		}
+	}
+	return cur, true
+}
+
+// listAt 返回 keypath 对应的数组，keypath 不存在时返回空数组
+func listAt(data map[string]interface{}, keyPath string) ([]interface{}, error) {
+	v, ok := lookupKeyPath(data, keyPath)
+	if !ok || v == nil {
+		return nil, nil
+	}
+
+	list, ok := v.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("keypath %s: not an array", keyPath)
+	}
+	return list, nil
+}
+
+// parentOf 返回 keypath 最后一级的父节点，create 为 true 时创建不存在的对象
+func parentOf(data map[string]interface{}, keyPath string, create bool) (interface{}, string, error) {
+	keys := strings.Split(keyPath, ".")
+
+	var cur interface{} = data
+	for _, key := range keys[:len(keys)-1] {
+		switch c := cur.(type) {
+		case map[string]interface{}:
+			next, ok := c[key]
+			if !ok || next == nil {
+				if !create {
+					return nil, "", nil
+				}
+				next = map[string]interface{}{}
+				c[key] = next
+			}
+			cur = next
+		case []interface{}:
+			i, err := strconv.Atoi(key)
+			if err != nil || i < 0 || i >= len(c) {
+				return nil, "", fmt.Errorf("keypath %s: invalid index %s", keyPath, key)
+			}
+			cur = c[i]
+		default:
+			return nil, "", fmt.Errorf("keypath %s: %s is not an object or array", keyPath, key)
+		}
+	}
+	return cur, keys[len(keys)-1], nil
+}
+
+func setKeyPath(data map[string]interface{}, keyPath string, value interface{}) error {
+	parent, key, err := parentOf(data, keyPath, true)
+	if err != nil {
+		return err
+	}
+
+	switch p := parent.(type) {
+	case map[string]interface{}:
+		p[key] = value
+	case []interface{}:
+		i, err := strconv.Atoi(key)
+		if err != nil || i < 0 || i >= len(p) {
+			return fmt.Errorf("keypath %s: invalid index %s", keyPath, key)
+		}
+		p[i] = value
+	default:
+		return fmt.Errorf("keypath %s: parent is not an object or array", keyPath)
+	}
+	return nil
+}
+
+func unsetKeyPath(data map[string]interface{}, keyPath string) error {
+	parent, key, err := parentOf(data, keyPath, false)
+	if err != nil || parent == nil {
+		return err
+	}
+
+	switch p := parent.(type) {
+	case map[string]interface{}:
+		delete(p, key)
+	case []interface{}:
+		i, err := strconv.Atoi(key)
+		if err != nil || i < 0 || i >= len(p) {
+			return fmt.Errorf("keypath %s: invalid index %s", keyPath, key)
+		}
+		p[i] = nil
+	}
+	return nil
+}
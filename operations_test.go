@@ -0,0 +1,110 @@
+package go_sdk
+
+import (
+	"encoding/json"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestUpdatePartApply(t *testing.T) {
+	Convey("Operations are applied to message data in order", t, func() {
+		var data map[string]interface{}
+		_ = json.Unmarshal([]byte(`{"title":"todo","list":[{"id":1,"done":false},{"id":2,"done":false},{"id":3,"done":false}]}`), &data)
+
+		ops := NewUpdatePart()
+		ops.AddOpSet(NewSet().Add("list.1.done", true).Add("owner.name", "u1"))
+		ops.AddOpUnset(NewUnset().Add("title"))
+		ops.AddOpRemove(NewRemove("list", []int{0, 2}))
+		ops.AddOpInsert(NewInsert("list", []struct {
+			ID   int  `json:"id"`
+			Done bool `json:"done"`
+		}{{4, false}}))
+		insert := NewInsert("list", []interface{}{map[string]interface{}{"id": 0}})
+		insert.Index = 0
+		ops.AddOpInsert(insert)
+
+		So(ops.Apply(data), ShouldBeNil)
+
+		b, _ := json.Marshal(data)
+		So(string(b), ShouldEqual, `{"list":[{"id":0},{"done":true,"id":2},{"done":false,"id":4}],"owner":{"name":"u1"}}`)
+
+		Convey("Invalid operations return an error unless IgnoreError is set", func() {
+			ops := NewUpdatePart()
+			ops.AddOpRemove(NewRemove("list", []int{5}))
+			ops.AddOpSet(NewSet().Add("list.0.id", 9))
+			So(ops.Apply(data), ShouldNotBeNil)
+			So(data["list"].([]interface{})[0].(map[string]interface{})["id"], ShouldEqual, 0)
+
+			ops.IgnoreError = true
+			So(ops.Apply(data), ShouldBeNil)
+			So(data["list"].([]interface{})[0].(map[string]interface{})["id"], ShouldEqual, 9)
+		})
+	})
+}
+
+func TestUpdatePartApplySemantics(t *testing.T) {
+	insertAt := func(keyPath string, list interface{}, index int) *Operation {
+		insert := NewInsert(keyPath, list)
+		insert.Index = index
+		return &Operation{Insert: insert}
+	}
+
+	cases := []struct {
+		name   string
+		data   string
+		op     *Operation
+		result string
+		fail   bool
+	}{
+		{"set replaces a value", `{"a":1}`, &Operation{Set: NewSet().Add("a", 2)}, `{"a":2}`, false},
+		{"set creates missing objects", `{}`, &Operation{Set: NewSet().Add("a.b.c", "x")}, `{"a":{"b":{"c":"x"}}}`, false},
+		{"set replaces a list element", `{"l":[1,2]}`, &Operation{Set: NewSet().Add("l.1", 3)}, `{"l":[1,3]}`, false},
+		{"set normalizes structs", `{}`, &Operation{Set: NewSet().Add("s", struct {
+			N int `json:"n"`
+		}{1})}, `{"s":{"n":1}}`, false},
+		{"set out of range index fails", `{"l":[1]}`, &Operation{Set: NewSet().Add("l.1", 3)}, `{"l":[1]}`, true},
+		{"set through a scalar fails", `{"a":1}`, &Operation{Set: NewSet().Add("a.b", 2)}, `{"a":1}`, true},
+		{"unset deletes a key", `{"a":1,"b":2}`, &Operation{Unset: NewUnset().Add("a")}, `{"b":2}`, false},
+		{"unset a missing key is ignored", `{"a":1}`, &Operation{Unset: NewUnset().Add("x.y")}, `{"a":1}`, false},
+		{"unset a list element sets null", `{"l":[1,2]}`, &Operation{Unset: NewUnset().Add("l.0")}, `{"l":[null,2]}`, false},
+		{"insert appends by default", `{"l":[1]}`, &Operation{Insert: NewInsert("l", []int{2, 3})}, `{"l":[1,2,3]}`, false},
+		{"insert at index", `{"l":[1,3]}`, insertAt("l", []int{2}, 1), `{"l":[1,2,3]}`, false},
+		{"insert creates a missing list", `{}`, &Operation{Insert: NewInsert("l", []int{1})}, `{"l":[1]}`, false},
+		{"insert out of range fails", `{"l":[1]}`, insertAt("l", []int{2}, 2), `{"l":[1]}`, true},
+		{"insert into a non list fails", `{"l":1}`, &Operation{Insert: NewInsert("l", []int{2})}, `{"l":1}`, true},
+		{"remove indexes", `{"l":[1,2,3]}`, &Operation{Remove: NewRemove("l", []int{0, 2})}, `{"l":[2]}`, false},
+		{"remove out of range fails", `{"l":[1]}`, &Operation{Remove: NewRemove("l", []int{1})}, `{"l":[1]}`, true},
+	}
+
+	Convey("Each operation is applied like the App does", t, func() {
+		for _, c := range cases {
+			c := c
+			Convey(c.name, func() {
+				var data map[string]interface{}
+				So(json.Unmarshal([]byte(c.data), &data), ShouldBeNil)
+
+				ops := NewUpdatePart()
+				ops.Ops = append(ops.Ops, *c.op)
+				err := ops.Apply(data)
+				if c.fail {
+					So(err, ShouldNotBeNil)
+				} else {
+					So(err, ShouldBeNil)
+				}
+
+				b, _ := json.Marshal(data)
+				So(string(b), ShouldEqual, c.result)
+			})
+		}
+	})
+
+	Convey("Nil data is rejected", t, func() {
+		ops := NewUpdatePart()
+		ops.AddOpSet(NewSet().Add("a", 1))
+		So(ops.Apply(nil), ShouldEqual, ErrNilData)
+
+		ops.IgnoreError = true
+		So(ops.Apply(nil), ShouldEqual, ErrNilData)
+	})
+}
@@ -0,0 +1,433 @@
+package go_sdk
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+)
+
+var (
+	ErrOutboxClosed = errors.New("outbox is closed")
+)
+
+// OutboxOp 表示发件箱中记录的操作类型
+type OutboxOp string
+
+const (
+	OutboxCreate OutboxOp = "create"
+	OutboxUpdate OutboxOp = "update"
+	OutboxDelete OutboxOp = "delete"
+)
+
+// OutboxEntry 为发件箱中一条待投递的操作
+type OutboxEntry struct {
+	ID string   `json:"id"`
+	Op OutboxOp `json:"op"`
+
+	// 根据 Op 的不同，分别使用以下字段
+	Create    *CreateMessageRequest `json:"create,omitempty"`
+	Update    *UpdateMessageRequest `json:"update,omitempty"`
+	MessageID int64                 `json:"messageID,omitempty"`
+
+	// 已投递次数、下次投递时间以及最后一次投递失败的原因
+	Attempts      int       `json:"attempts"`
+	NextAttemptAt time.Time `json:"nextAttemptAt"`
+	LastError     string    `json:"lastError,omitempty"`
+	CreatedAt     time.Time `json:"createdAt"`
+}
+
+// OutboxStore 定义了一套用来持久化发件箱记录的接口，SDK 实现了基于内存、文件和 SQL 数据库的存储
+type OutboxStore interface {
+	// Put 保存一条新的记录，或者更新已有记录的投递状态
+	Put(e *OutboxEntry) error
+	// Due 返回 NextAttemptAt 不晚于 now 的记录，按 CreatedAt 升序排列，最多返回 limit 条
+	Due(now time.Time, limit int) ([]*OutboxEntry, error)
+	// Remove 删除已投递成功的记录
+	Remove(id string) error
+	// DeadLetter 将无法投递的记录移入死信，不再投递
+	DeadLetter(e *OutboxEntry) error
+}
+
+// Outbox 是一个异步发件箱，处理函数将推送、更新和删除消息的操作写入发件箱后即可返回，
+// 发件箱在后台投递这些操作，平台接口暂时不可用时按指数退避重试，业务性错误或者重试次数用尽后
+// 将记录移入死信。使用持久化的 OutboxStore 时，服务重启后会继续投递未完成的记录：
+//      outbox := NewOutbox(client, NewFileOutboxStore("/var/lib/app/outbox"))
+//      outbox.Start()
+//      defer outbox.Close(context.Background())
+//
+//      _, err := outbox.Create(&CreateMessageRequest{...})
+type Outbox struct {
+	client *Client
+	store  OutboxStore
+
+	maxAttempts  int
+	minBackoff   time.Duration
+	maxBackoff   time.Duration
+	pollInterval time.Duration
+	batchSize    int
+	onDelivered  func(e *OutboxEntry, messageID int64)
+	onDeadLetter func(e *OutboxEntry, err error)
+
+	mutex   sync.Mutex
+	started bool
+	closed  bool
+	wake    chan struct{}
+	stop    chan struct{}
+	stopped chan struct{}
+}
+
+// OutboxOption 用于设置 Outbox 的可选参数
+type OutboxOption func(o *Outbox)
+
+// WithOutboxRetry 设置最大投递次数以及重试的退避时间范围，默认为 10 次，1 秒到 5 分钟
+func WithOutboxRetry(maxAttempts int, minBackoff, maxBackoff time.Duration) OutboxOption {
+	return func(o *Outbox) {
+		o.maxAttempts = maxAttempts
+		o.minBackoff = minBackoff
+		o.maxBackoff = maxBackoff
+	}
+}
+
+// WithOutboxPollInterval 设置检查待投递记录的时间间隔，默认为 1 秒
+func WithOutboxPollInterval(d time.Duration) OutboxOption {
+	return func(o *Outbox) {
+		o.pollInterval = d
+	}
+}
+
+// WithOutboxDelivered 设置投递成功的回调，对于 create 操作，messageID 为新消息的 ID
+func WithOutboxDelivered(fn func(e *OutboxEntry, messageID int64)) OutboxOption {
+	return func(o *Outbox) {
+		o.onDelivered = fn
+	}
+}
+
+// WithOutboxDeadLetter 设置记录移入死信时的回调，可用于记录日志或者报警
+func WithOutboxDeadLetter(fn func(e *OutboxEntry, err error)) OutboxOption {
+	return func(o *Outbox) {
+		o.onDeadLetter = fn
+	}
+}
+
+func NewOutbox(client *Client, store OutboxStore, opts ...OutboxOption) *Outbox {
+	o := &Outbox{
+		client:       client,
+		store:        store,
+		maxAttempts:  10,
+		minBackoff:   time.Second,
+		maxBackoff:   5 * time.Minute,
+		pollInterval: time.Second,
+		batchSize:    50,
+		wake:         make(chan struct{}, 1),
+		stop:         make(chan struct{}),
+		stopped:      make(chan struct{}),
+	}
+
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// Start 启动后台投递，重复调用或者 Close 之后调用不会有任何效果
+func (o *Outbox) Start() {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+	if o.started || o.closed {
+		return
+	}
+
+	o.started = true
+	go o.run()
+}
+
+// Create 将推送消息的操作写入发件箱，返回记录的 ID
+func (o *Outbox) Create(cmr *CreateMessageRequest) (string, error) {
+	if err := cmr.check(); err != nil {
+		return "", err
+	}
+
+	return o.put(&OutboxEntry{Op: OutboxCreate, Create: cmr})
+}
+
+// Update 将更新消息的操作写入发件箱，返回记录的 ID
+func (o *Outbox) Update(umr *UpdateMessageRequest) (string, error) {
+	if umr.ID <= 0 {
+		return "", ErrMessageIDRequired
+	}
+
+	if err := umr.MessageContentRequest.check(); err != nil {
+		return "", err
+	}
+
+	return o.put(&OutboxEntry{Op: OutboxUpdate, Update: umr})
+}
+
+// Delete 将删除消息的操作写入发件箱，返回记录的 ID
+func (o *Outbox) Delete(messageID int64) (string, error) {
+	if messageID <= 0 {
+		return "", ErrMessageIDRequired
+	}
+
+	return o.put(&OutboxEntry{Op: OutboxDelete, MessageID: messageID})
+}
+
+func (o *Outbox) put(e *OutboxEntry) (string, error) {
+	o.mutex.Lock()
+	closed := o.closed
+	o.mutex.Unlock()
+	if closed {
+		return "", ErrOutboxClosed
+	}
+
+	e.ID = newEntryID()
+	e.CreatedAt = time.Now()
+	e.NextAttemptAt = e.CreatedAt
+	if err := o.store.Put(e); err != nil {
+		return "", err
+	}
+
+	select {
+	case o.wake <- struct{}{}:
+	default:
+	}
+	return e.ID, nil
+}
+
+// Close 停止接收新的记录，并投递所有已到期的记录后返回，ctx 取消时立即返回 ctx.Err()。
+// 正在等待重试的记录保留在存储中，下次启动后继续投递。没有调用过 Start 时同样会投递已到期的记录
+func (o *Outbox) Close(ctx context.Context) error {
+	o.mutex.Lock()
+	if o.closed {
+		o.mutex.Unlock()
+		return ErrOutboxClosed
+	}
+	o.closed = true
+	started := o.started
+	o.mutex.Unlock()
+
+	close(o.stop)
+	if started {
+		select {
+		case <-o.stopped:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	for {
+		n, err := o.deliverDue(ctx)
+		if err != nil {
+			return err
+		}
+
+		if n == 0 {
+			return nil
+		}
+	}
+}
+
+func (o *Outbox) run() {
+	defer close(o.stopped)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		select {
+		case <-o.stop:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	// 存储出错时按指数退避等待，避免反复处理同一条记录
+	var failures int
+	for {
+		var err error
+		for {
+			// 一次取出的记录都投递完了，可能还有更多到期的记录
+			var n int
+			n, err = o.deliverDue(ctx)
+			if err != nil || n < o.batchSize {
+				break
+			}
+		}
+
+		wait := o.pollInterval
+		if err != nil && ctx.Err() == nil {
+			failures++
+			wait = o.backoff(failures)
+			o.client.log().Error("outbox store failed", "failures", failures, "retryIn", wait, "error", err.Error())
+		} else {
+			failures = 0
+		}
+
+		// 存储出错时只按退避时间重试，不因新写入的记录提前唤醒
+		wake := o.wake
+		if failures > 0 {
+			wake = nil
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-o.stop:
+			timer.Stop()
+			return
+		case <-wake:
+		case <-timer.C:
+		}
+		timer.Stop()
+	}
+}
+
+// deliverDue 投递一批到期的记录，返回处理的记录数
+func (o *Outbox) deliverDue(ctx context.Context) (int, error) {
+	entries, err := o.store.Due(time.Now(), o.batchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	for i, e := range entries {
+		if ctx.Err() != nil {
+			return i, ctx.Err()
+		}
+
+		if err = o.deliver(ctx, e); err != nil {
+			return i, err
+		}
+	}
+
+	return len(entries), nil
+}
+
+// deliver 投递一条记录，只在更新存储失败时返回错误
+func (o *Outbox) deliver(ctx context.Context, e *OutboxEntry) error {
+	var messageID int64
+	var err error
+	switch e.Op {
+	case OutboxCreate:
+		messageID, err = o.client.CreateMessageContext(ctx, e.Create)
+	case OutboxUpdate:
+		err = o.client.UpdateMessageContext(ctx, e.Update)
+	case OutboxDelete:
+		err = o.client.DeleteMessageContext(ctx, e.MessageID)
+	default:
+		err = errors.New("unknown outbox op " + string(e.Op))
+	}
+
+	if err == nil {
+		if o.onDelivered != nil {
+			o.onDelivered(e, messageID)
+		}
+		// 删除失败时记录会被再次投递
+		return o.store.Remove(e.ID)
+	}
+
+	// 关闭发件箱导致的请求取消不计入投递次数
+	if ctx.Err() != nil {
+		return nil
+	}
+
+	e.Attempts++
+	e.LastError = err.Error()
+	if !o.client.shouldRetry(err) || e.Attempts >= o.maxAttempts {
+		o.client.log().Error("outbox entry dead-lettered", "id", e.ID, "op", string(e.Op), "attempts", e.Attempts, "error", e.LastError)
+		if o.onDeadLetter != nil {
+			o.onDeadLetter(e, err)
+		}
+		return o.store.DeadLetter(e)
+	}
+
+	e.NextAttemptAt = time.Now().Add(o.backoff(e.Attempts))
+	o.client.log().Warn("outbox entry will be retried", "id", e.ID, "op", string(e.Op), "attempts", e.Attempts, "nextAttemptAt", e.NextAttemptAt, "error", e.LastError)
+	return o.store.Put(e)
+}
+
+func (o *Outbox) backoff(attempts int) time.Duration {
+	d := o.minBackoff
+	for i := 1; i < attempts && d < o.maxBackoff; i++ {
+		d *= 2
+	}
+
+	if d > o.maxBackoff {
+		d = o.maxBackoff
+	}
+	return d
+}
+
+func newEntryID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// MemoryOutboxStore 实现了基于内存的 OutboxStore 接口，服务重启后未投递的记录将丢失，适用于开发和测试
+type MemoryOutboxStore struct {
+	mutex   sync.Mutex
+	entries map[string]*OutboxEntry
+	dead    []*OutboxEntry
+}
+
+func NewMemoryOutboxStore() *MemoryOutboxStore {
+	return &MemoryOutboxStore{
+		entries: make(map[string]*OutboxEntry),
+	}
+}
+
+func (s *MemoryOutboxStore) Put(e *OutboxEntry) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	c := *e
+	s.entries[e.ID] = &c
+	return nil
+}
+
+func (s *MemoryOutboxStore) Due(now time.Time, limit int) ([]*OutboxEntry, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	var due []*OutboxEntry
+	for _, e := range s.entries {
+		if !e.NextAttemptAt.After(now) {
+			c := *e
+			due = append(due, &c)
+		}
+	}
+
+	sort.Slice(due, func(i, j int) bool { return due[i].CreatedAt.Before(due[j].CreatedAt) })
+	if len(due) > limit {
+		due = due[:limit]
+	}
+	return due, nil
+}
+
+func (s *MemoryOutboxStore) Remove(id string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	delete(s.entries, id)
+	return nil
+}
+
+func (s *MemoryOutboxStore) DeadLetter(e *OutboxEntry) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	delete(s.entries, e.ID)
+	c := *e
+	s.dead = append(s.dead, &c)
+	return nil
+}
+
+// DeadLetters 返回所有已移入死信的记录
+func (s *MemoryOutboxStore) DeadLetters() []*OutboxEntry {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return append([]*OutboxEntry(nil), s.dead...)
+}
@@ -0,0 +1,252 @@
+package go_sdk
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// FileOutboxStore 实现了基于文件的 OutboxStore 接口，每条记录保存为 dir/pending 目录下的一个 JSON 文件，
+// 死信保存在 dir/dead 目录下。适用于单实例部署、记录数量不多的场景
+type FileOutboxStore struct {
+	dir   string
+	mutex sync.Mutex
+}
+
+func NewFileOutboxStore(dir string) *FileOutboxStore {
+	return &FileOutboxStore{dir: dir}
+}
+
+func (s *FileOutboxStore) Put(e *OutboxEntry) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return s.write(filepath.Join(s.dir, "pending"), e)
+}
+
+func (s *FileOutboxStore) Due(now time.Time, limit int) ([]*OutboxEntry, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	dir := filepath.Join(s.dir, "pending")
+	infos, err := ioutil.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var due []*OutboxEntry
+	for _, info := range infos {
+		if info.IsDir() || !strings.HasSuffix(info.Name(), ".json") {
+			continue
+		}
+
+		b, err := ioutil.ReadFile(filepath.Join(dir, info.Name()))
+		if err != nil {
+			return nil, err
+		}
+
+		e := &OutboxEntry{}
+		if err = json.Unmarshal(b, e); err != nil {
+			return nil, fmt.Errorf("%s: %s", info.Name(), err)
+		}
+
+		if !e.NextAttemptAt.After(now) {
+			due = append(due, e)
+		}
+	}
+
+	sort.Slice(due, func(i, j int) bool { return due[i].CreatedAt.Before(due[j].CreatedAt) })
+	if len(due) > limit {
+		due = due[:limit]
+	}
+	return due, nil
+}
+
+func (s *FileOutboxStore) Remove(id string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	err := os.Remove(filepath.Join(s.dir, "pending", id+".json"))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
+
+func (s *FileOutboxStore) DeadLetter(e *OutboxEntry) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if err := s.write(filepath.Join(s.dir, "dead"), e); err != nil {
+		return err
+	}
+
+	err := os.Remove(filepath.Join(s.dir, "pending", e.ID+".json"))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
+
+// write 先写入临时文件再重命名，避免进程中断时留下不完整的文件
+func (s *FileOutboxStore) write(dir string, e *OutboxEntry) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	b, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+
+	tmp := filepath.Join(dir, "."+e.ID+".tmp")
+	if err = ioutil.WriteFile(tmp, b, 0644); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, filepath.Join(dir, e.ID+".json"))
+}
+
+// SQLOutboxStore 实现了基于 SQL 数据库的 OutboxStore 接口，可以在多个实例间共享，表结构如下（以 MySQL 为例）：
+//      CREATE TABLE sm_outbox (
+//          id              VARCHAR(32) NOT NULL PRIMARY KEY,
+//          payload         TEXT        NOT NULL,
+//          next_attempt_at BIGINT      NOT NULL,
+//          created_at      BIGINT      NOT NULL,
+//          dead            TINYINT     NOT NULL DEFAULT 0
+//      );
+// 时间字段保存为毫秒级的 UNIX 时间戳。默认使用 ? 作为参数占位符，PostgreSQL 等使用 $1 占位符的
+// 数据库请设置 DollarPlaceholder 为 true。多个实例同时投递时，同一条记录可能被重复投递
+type SQLOutboxStore struct {
+	DB                *sql.DB
+	Table             string
+	DollarPlaceholder bool
+}
+
+func NewSQLOutboxStore(db *sql.DB, table string) *SQLOutboxStore {
+	return &SQLOutboxStore{DB: db, Table: table}
+}
+
+func (s *SQLOutboxStore) query(q string) string {
+	return sqlQuery(q, s.Table, s.DollarPlaceholder)
+}
+
+// sqlQuery 将查询语句中的 {table} 替换为表名，? 占位符替换为数据库支持的形式
+func sqlQuery(q, table string, dollarPlaceholder bool) string {
+	q = strings.Replace(q, "{table}", table, -1)
+	if !dollarPlaceholder {
+		return q
+	}
+
+	b := new(strings.Builder)
+	n := 0
+	for _, r := range q {
+		if r == '?' {
+			n++
+			fmt.Fprintf(b, "$%d", n)
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+func (s *SQLOutboxStore) Put(e *OutboxEntry) error {
+	payload, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+
+	return sqlUpsert(s.DB,
+		s.query("SELECT COUNT(*) FROM {table} WHERE id = ?"), e.ID,
+		s.query("UPDATE {table} SET payload = ?, next_attempt_at = ? WHERE id = ?"),
+		[]interface{}{string(payload), toMillis(e.NextAttemptAt), e.ID},
+		s.query("INSERT INTO {table} (id, payload, next_attempt_at, created_at, dead) VALUES (?, ?, ?, ?, 0)"),
+		[]interface{}{e.ID, string(payload), toMillis(e.NextAttemptAt), toMillis(e.CreatedAt)})
+}
+
+func (s *SQLOutboxStore) Due(now time.Time, limit int) ([]*OutboxEntry, error) {
+	rows, err := s.DB.Query(s.query("SELECT payload FROM {table} WHERE dead = 0 AND next_attempt_at <= ? ORDER BY created_at LIMIT ?"),
+		toMillis(now), limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var due []*OutboxEntry
+	for rows.Next() {
+		var payload string
+		if err = rows.Scan(&payload); err != nil {
+			return nil, err
+		}
+
+		e := &OutboxEntry{}
+		if err = json.Unmarshal([]byte(payload), e); err != nil {
+			return nil, err
+		}
+		due = append(due, e)
+	}
+
+	return due, rows.Err()
+}
+
+func (s *SQLOutboxStore) Remove(id string) error {
+	_, err := s.DB.Exec(s.query("DELETE FROM {table} WHERE id = ?"), id)
+	return err
+}
+
+func (s *SQLOutboxStore) DeadLetter(e *OutboxEntry) error {
+	payload, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.DB.Exec(s.query("UPDATE {table} SET payload = ?, dead = 1 WHERE id = ?"), string(payload), e.ID)
+	return err
+}
+
+// sqlUpsert 在 exists 查询到 key 对应的记录时执行 update，否则执行 insert。
+// 不依赖 UPDATE 的 RowsAffected（MySQL 在数据没有变化时返回 0），
+// insert 因其它实例同时写入而主键冲突时改为执行 update
+func sqlUpsert(db *sql.DB, exists string, key interface{}, update string, updateArgs []interface{}, insert string, insertArgs []interface{}) error {
+	found, err := sqlExists(db, exists, key)
+	if err != nil {
+		return err
+	}
+
+	if !found {
+		_, err = db.Exec(insert, insertArgs...)
+		if err == nil {
+			return nil
+		}
+
+		if found, e := sqlExists(db, exists, key); e != nil || !found {
+			return err
+		}
+	}
+
+	_, err = db.Exec(update, updateArgs...)
+	return err
+}
+
+func sqlExists(db *sql.DB, q string, key interface{}) (bool, error) {
+	var n int
+	if err := db.QueryRow(q, key).Scan(&n); err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
+
+func toMillis(t time.Time) int64 {
+	return t.UnixNano() / int64(time.Millisecond)
+}
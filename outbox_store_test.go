@@ -0,0 +1,112 @@
+package go_sdk
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func newOutboxEntry(id string, createdAt time.Time) *OutboxEntry {
+	return &OutboxEntry{ID: id, Op: OutboxDelete, MessageID: 1, CreatedAt: createdAt, NextAttemptAt: createdAt}
+}
+
+func TestFileOutboxStore(t *testing.T) {
+	Convey("Persist entries as files", t, func() {
+		dir := t.TempDir()
+		store := NewFileOutboxStore(dir)
+		now := time.Now()
+
+		due, err := store.Due(now, 10)
+		So(err, ShouldBeNil)
+		So(due, ShouldBeEmpty)
+
+		So(store.Put(newOutboxEntry("b", now.Add(-time.Second))), ShouldBeNil)
+		So(store.Put(newOutboxEntry("a", now.Add(-2*time.Second))), ShouldBeNil)
+		later := newOutboxEntry("c", now)
+		later.NextAttemptAt = now.Add(time.Hour)
+		So(store.Put(later), ShouldBeNil)
+
+		// 重新打开目录，模拟服务重启
+		store = NewFileOutboxStore(dir)
+		due, err = store.Due(now, 10)
+		So(err, ShouldBeNil)
+		So(len(due), ShouldEqual, 2)
+		So(due[0].ID, ShouldEqual, "a")
+		So(due[1].ID, ShouldEqual, "b")
+
+		b := due[1]
+		due, err = store.Due(now, 1)
+		So(err, ShouldBeNil)
+		So(len(due), ShouldEqual, 1)
+
+		So(store.Remove("a"), ShouldBeNil)
+		So(store.Remove("a"), ShouldBeNil)
+		So(store.DeadLetter(b), ShouldBeNil)
+
+		due, err = store.Due(now.Add(2*time.Hour), 10)
+		So(err, ShouldBeNil)
+		So(len(due), ShouldEqual, 1)
+		So(due[0].ID, ShouldEqual, "c")
+	})
+}
+
+func TestSQLOutboxStore(t *testing.T) {
+	Convey("Upsert entries without relying on RowsAffected", t, func() {
+		db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		So(err, ShouldBeNil)
+		defer db.Close()
+
+		store := NewSQLOutboxStore(db, "sm_outbox")
+		e := newOutboxEntry("e1", time.Unix(100, 0))
+
+		count := "SELECT COUNT(*) FROM sm_outbox WHERE id = ?"
+		insert := "INSERT INTO sm_outbox (id, payload, next_attempt_at, created_at, dead) VALUES (?, ?, ?, ?, 0)"
+		update := "UPDATE sm_outbox SET payload = ?, next_attempt_at = ? WHERE id = ?"
+
+		// 新记录
+		mock.ExpectQuery(count).WithArgs("e1").WillReturnRows(sqlmock.NewRows([]string{"n"}).AddRow(0))
+		mock.ExpectExec(insert).WithArgs("e1", sqlmock.AnyArg(), int64(100000), int64(100000)).WillReturnResult(sqlmock.NewResult(1, 1))
+		So(store.Put(e), ShouldBeNil)
+
+		// 已有记录，即使数据没有变化（RowsAffected 为 0）也不会再插入
+		mock.ExpectQuery(count).WithArgs("e1").WillReturnRows(sqlmock.NewRows([]string{"n"}).AddRow(1))
+		mock.ExpectExec(update).WithArgs(sqlmock.AnyArg(), int64(100000), "e1").WillReturnResult(sqlmock.NewResult(0, 0))
+		So(store.Put(e), ShouldBeNil)
+
+		// 其它实例同时插入导致主键冲突
+		mock.ExpectQuery(count).WithArgs("e1").WillReturnRows(sqlmock.NewRows([]string{"n"}).AddRow(0))
+		mock.ExpectExec(insert).WillReturnError(errors.New("duplicate entry"))
+		mock.ExpectQuery(count).WithArgs("e1").WillReturnRows(sqlmock.NewRows([]string{"n"}).AddRow(1))
+		mock.ExpectExec(update).WillReturnResult(sqlmock.NewResult(0, 1))
+		So(store.Put(e), ShouldBeNil)
+
+		So(mock.ExpectationsWereMet(), ShouldBeNil)
+	})
+
+	Convey("Query due entries with dollar placeholders", t, func() {
+		db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		So(err, ShouldBeNil)
+		defer db.Close()
+
+		store := NewSQLOutboxStore(db, "sm_outbox")
+		store.DollarPlaceholder = true
+
+		mock.ExpectQuery("SELECT payload FROM sm_outbox WHERE dead = 0 AND next_attempt_at <= $1 ORDER BY created_at LIMIT $2").
+			WithArgs(int64(5000), 10).
+			WillReturnRows(sqlmock.NewRows([]string{"payload"}).AddRow(`{"id":"e1","op":"delete","messageID":3}`))
+		due, err := store.Due(time.Unix(5, 0), 10)
+		So(err, ShouldBeNil)
+		So(len(due), ShouldEqual, 1)
+		So(due[0].MessageID, ShouldEqual, 3)
+
+		mock.ExpectExec("UPDATE sm_outbox SET payload = $1, dead = 1 WHERE id = $2").WillReturnResult(sqlmock.NewResult(0, 1))
+		So(store.DeadLetter(due[0]), ShouldBeNil)
+		mock.ExpectExec("DELETE FROM sm_outbox WHERE id = $1").WithArgs("e1").WillReturnResult(sqlmock.NewResult(0, 1))
+		So(store.Remove("e1"), ShouldBeNil)
+
+		So(mock.ExpectationsWereMet(), ShouldBeNil)
+	})
+}
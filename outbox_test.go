@@ -0,0 +1,132 @@
+package go_sdk
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestOutbox(t *testing.T) {
+	var failures int64 = 2
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		cmr := &CreateMessageRequest{}
+		_ = json.NewDecoder(r.Body).Decode(cmr)
+		switch cmr.Title {
+		case "flaky":
+			if atomic.AddInt64(&failures, -1) >= 0 {
+				w.WriteHeader(http.StatusBadGateway)
+				return
+			}
+		case "invalid":
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{"code": 20001, "message": "invalid recipient"})
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"code": 0, "data": map[string]interface{}{"id": 1}})
+	}))
+	defer server.Close()
+
+	originHost := apiHost
+	apiHost = server.URL
+	defer func() { apiHost = originHost }()
+
+	Convey("Deliver entries in the background", t, func() {
+		store := NewMemoryOutboxStore()
+		delivered := make(chan string, 3)
+		dead := make(chan string, 3)
+		outbox := NewOutbox(NewClient("token", nil), store,
+			WithOutboxRetry(5, 10*time.Millisecond, 50*time.Millisecond),
+			WithOutboxPollInterval(10*time.Millisecond),
+			WithOutboxDelivered(func(e *OutboxEntry, messageID int64) {
+				delivered <- e.Create.Title
+			}),
+			WithOutboxDeadLetter(func(e *OutboxEntry, err error) {
+				dead <- e.Create.Title
+			}))
+		outbox.Start()
+
+		for _, title := range []string{"ok", "flaky", "invalid"} {
+			_, err := outbox.Create(&CreateMessageRequest{
+				ToAll:                 true,
+				MessageContentRequest: MessageContentRequest{TemplateID: "t1", TemplateVersion: 1, Title: title},
+			})
+			So(err, ShouldBeNil)
+		}
+
+		_, err := outbox.Create(&CreateMessageRequest{})
+		So(err, ShouldEqual, ErrTemplateIDRequired)
+
+		var titles []string
+		timeout := time.After(5 * time.Second)
+		for len(titles) < 3 {
+			select {
+			case title := <-delivered:
+				titles = append(titles, title)
+			case title := <-dead:
+				titles = append(titles, "dead:"+title)
+			case <-timeout:
+				t.Fatal("outbox entries not delivered in time")
+			}
+		}
+		So(titles, ShouldContain, "ok")
+		So(titles, ShouldContain, "flaky")
+		So(titles, ShouldContain, "dead:invalid")
+		So(outbox.Close(context.Background()), ShouldBeNil)
+
+		deadLetters := store.DeadLetters()
+		So(len(deadLetters), ShouldEqual, 1)
+		So(deadLetters[0].Create.Title, ShouldEqual, "invalid")
+		So(deadLetters[0].Attempts, ShouldEqual, 1)
+
+		_, err = outbox.Delete(1)
+		So(err, ShouldEqual, ErrOutboxClosed)
+	})
+
+	Convey("Close delivers due entries without Start", t, func() {
+		store := NewMemoryOutboxStore()
+		outbox := NewOutbox(NewClient("token", nil), store)
+		_, err := outbox.Create(&CreateMessageRequest{
+			ToAll:                 true,
+			MessageContentRequest: MessageContentRequest{TemplateID: "t1", TemplateVersion: 1, Title: "ok"},
+		})
+		So(err, ShouldBeNil)
+
+		So(outbox.Close(context.Background()), ShouldBeNil)
+		due, _ := store.Due(time.Now(), 10)
+		So(due, ShouldBeEmpty)
+		So(outbox.Close(context.Background()), ShouldEqual, ErrOutboxClosed)
+	})
+
+	Convey("Store errors are returned instead of retrying the same entry", t, func() {
+		store := &failingOutboxStore{MemoryOutboxStore: NewMemoryOutboxStore()}
+		outbox := NewOutbox(NewClient("token", nil), store)
+		_, err := outbox.Create(&CreateMessageRequest{
+			ToAll:                 true,
+			MessageContentRequest: MessageContentRequest{TemplateID: "t1", TemplateVersion: 1, Title: "ok"},
+		})
+		So(err, ShouldBeNil)
+
+		store.failRemove = true
+		So(outbox.Close(context.Background()), ShouldEqual, errStoreUnavailable)
+	})
+}
+
+var errStoreUnavailable = errors.New("store unavailable")
+
+type failingOutboxStore struct {
+	*MemoryOutboxStore
+	failRemove bool
+}
+
+func (s *failingOutboxStore) Remove(id string) error {
+	if s.failRemove {
+		return errStoreUnavailable
+	}
+	return s.MemoryOutboxStore.Remove(id)
+}
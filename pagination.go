@@ -0,0 +1,122 @@
+package go_sdk
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+)
+
+var ErrPageItemsNotList = errors.New("page items must be a slice or an array")
+
+// PageFetchFunc 返回 cursor 之后的一页数据，items 必须是一个数组，nextCursor 为空表示没有更多内容。
+// 第一页的 cursor 为空。ServeHTTP 验证 request token 后，可以通过 MemberFromContext(ctx) 获取请求的成员
+type PageFetchFunc func(ctx context.Context, cursor string) (items interface{}, nextCursor string, err error)
+
+// Paginator 实现了上滑滚动翻页：从请求中读取 cursor，调用 Fetch 获取下一页，将其追加到消息数据中
+// ListKeyPath 对应的列表末尾，没有更多内容时设置 noMoreContents。
+// 下一页的 cursor 保存在消息数据的 CursorKeyPath 中，模板中的加载操作通过 {{}} 将其带回，比如：
+//      api:get="/todos/more?cursor={{nextCursor}}"
+// 设置 Client 后 ServeHTTP 会先验证请求的 request token，否则需要像其它处理函数一样挂载在验证 request token 的中间件之后。
+// 用法：
+//      paginator := go_sdk.NewPaginator(TodoListKeyList, func(ctx context.Context, cursor string) (interface{}, string, error) {
+//          member, _ := go_sdk.MemberFromContext(ctx)
+//          return store.ListTodo(ctx, member.OpenID, cursor, 20)
+//      })
+//      paginator.Client = client
+//      router.Methods("GET").Path("/todos/more").Handler(paginator)
+type Paginator struct {
+	// 列表在消息数据中的 keypath
+	ListKeyPath string
+	// 下一页的 cursor 在消息数据中的 keypath，默认为 "nextCursor"
+	CursorKeyPath string
+	// 请求中 cursor 参数的名称，默认为 "cursor"
+	CursorParam string
+	// 获取数据出错时 ServeHTTP 显示的提示
+	ErrorTip string
+
+	// 用于验证 request token，为 nil 时 ServeHTTP 不验证
+	Client *Client
+	// request token 验证失败时 ServeHTTP 显示的提示
+	VerifyErrorTip string
+
+	Fetch PageFetchFunc
+}
+
+func NewPaginator(listKeyPath string, fetch PageFetchFunc) *Paginator {
+	return &Paginator{
+		ListKeyPath:    listKeyPath,
+		CursorKeyPath:  "nextCursor",
+		CursorParam:    "cursor",
+		ErrorTip:       "加载失败，请稍后重试",
+		VerifyErrorTip: "无法验证身份",
+		Fetch:          fetch,
+	}
+}
+
+// NewPaginatorOf 与 NewPaginator 相同，列表通过 smgen 生成的字段指定，fetch 返回的元素类型在编译期确定
+func NewPaginatorOf[T, V any](list Field[T, []V], fetch func(ctx context.Context, cursor string) ([]V, string, error)) *Paginator {
+	return NewPaginator(list.KeyPath(), func(ctx context.Context, cursor string) (interface{}, string, error) {
+		return fetch(ctx, cursor)
+	})
+}
+
+// UpdatePart 根据请求中的 cursor 获取下一页，返回追加数据的 UpdatePart，items 不是数组时返回 ErrPageItemsNotList。
+// 只有追加了数据时才更新消息数据中的 cursor
+func (p *Paginator) UpdatePart(r *http.Request) (*UpdatePart, error) {
+	param := p.CursorParam
+	if param == "" {
+		param = "cursor"
+	}
+	cursor := strings.TrimSpace(r.URL.Query().Get(param))
+
+	items, nextCursor, err := p.Fetch(r.Context(), cursor)
+	if err != nil {
+		return nil, err
+	}
+
+	insert := NewInsert(p.ListKeyPath, items)
+	if insert == nil && items != nil {
+		return nil, ErrPageItemsNotList
+	}
+
+	ops := NewUpdatePart()
+	if insert != nil && len(insert.Ele) > 0 {
+		ops.AddOpInsert(insert)
+		if p.CursorKeyPath != "" {
+			ops.AddOpSet(NewSet().Add(p.CursorKeyPath, nextCursor))
+		}
+	}
+
+	if nextCursor == "" {
+		ops.MarkNoMoreContents()
+	}
+	return ops, nil
+}
+
+// ServeHTTP 输出翻页的响应，设置了 Client 时先验证 request token，验证失败时显示 VerifyErrorTip，
+// 验证通过的成员通过 context 传给 Fetch。获取数据出错时显示 ErrorTip
+func (p *Paginator) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if p.Client != nil {
+		var member Member
+		q, err := QueryParameterFrom(r)
+		if err == nil {
+			member, err = p.Client.VerifyRequestTokenContext(r.Context(), q.RequestToken)
+		}
+		if err != nil {
+			getLogger().Warn("failed to verify request token", "keyPath", p.ListKeyPath, "error", err.Error())
+			_ = ShowError(w, p.VerifyErrorTip)
+			return
+		}
+		r = r.WithContext(ContextWithMember(r.Context(), member))
+	}
+
+	ops, err := p.UpdatePart(r)
+	if err != nil {
+		getLogger().Error("failed to fetch page", "keyPath", p.ListKeyPath, "error", err.Error())
+		_ = ShowError(w, p.ErrorTip)
+		return
+	}
+
+	_ = NewResponse().UpdatePartData(ops).Output(w)
+}
@@ -0,0 +1,124 @@
+package go_sdk
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestPaginator(t *testing.T) {
+	pages := map[string][]int{"": {1, 2}, "p2": {3}}
+	next := map[string]string{"": "p2"}
+	list := NewField[map[string]interface{}, []int]("list")
+	paginator := NewPaginatorOf(list, func(ctx context.Context, cursor string) ([]int, string, error) {
+		if cursor == "bad" {
+			return nil, "", errors.New("bad cursor")
+		}
+		return pages[cursor], next[cursor], nil
+	})
+
+	Convey("Append pages until exhausted", t, func() {
+		data := map[string]interface{}{"list": []interface{}{}}
+
+		ops, err := paginator.UpdatePart(httptest.NewRequest("GET", "/more", nil))
+		So(err, ShouldBeNil)
+		So(ops.NoMoreContents, ShouldBeFalse)
+		So(ops.Ops, ShouldHaveLength, 2)
+		So(ops.Ops[0].Insert.KeyPath, ShouldEqual, "list")
+		So(ops.Ops[0].Insert.Index, ShouldEqual, -1)
+		So(ops.Apply(data), ShouldBeNil)
+		So(data["nextCursor"], ShouldEqual, "p2")
+
+		ops, err = paginator.UpdatePart(httptest.NewRequest("GET", "/more?cursor=p2", nil))
+		So(err, ShouldBeNil)
+		So(ops.NoMoreContents, ShouldBeTrue)
+		So(ops.Ops[0].Insert.Index, ShouldEqual, -1)
+		So(ops.Ops[0].Insert.Ele, ShouldResemble, []interface{}{3})
+		So(ops.Apply(data), ShouldBeNil)
+		So(data["list"], ShouldResemble, []interface{}{1.0, 2.0, 3.0})
+		So(data["nextCursor"], ShouldEqual, "")
+	})
+
+	Convey("Serve pages over HTTP", t, func() {
+		w := httptest.NewRecorder()
+		paginator.ServeHTTP(w, httptest.NewRequest("GET", "/more?cursor=p2", nil))
+		resp := &Response{}
+		So(json.NewDecoder(w.Body).Decode(resp), ShouldBeNil)
+		So(resp.UpdatePart.NoMoreContents, ShouldBeTrue)
+		So(resp.UpdatePart.Ops[0].Insert.Index, ShouldEqual, -1)
+
+		w = httptest.NewRecorder()
+		paginator.ServeHTTP(w, httptest.NewRequest("GET", "/more?cursor=bad", nil))
+		resp = &Response{}
+		So(json.NewDecoder(w.Body).Decode(resp), ShouldBeNil)
+		So(resp.Dismiss.Type, ShouldEqual, Error)
+	})
+
+	Convey("The last page is empty when nothing is left", t, func() {
+		empty := NewPaginator("list", func(ctx context.Context, cursor string) (interface{}, string, error) {
+			return []int{}, "", nil
+		})
+		ops, err := empty.UpdatePart(httptest.NewRequest("GET", "/more?cursor=p3", nil))
+		So(err, ShouldBeNil)
+		So(ops.NoMoreContents, ShouldBeTrue)
+		So(ops.Ops, ShouldBeEmpty)
+	})
+
+	Convey("Items that are not a list are rejected", t, func() {
+		invalid := NewPaginator("list", func(ctx context.Context, cursor string) (interface{}, string, error) {
+			return map[string]int{"a": 1}, "p2", nil
+		})
+		_, err := invalid.UpdatePart(httptest.NewRequest("GET", "/more", nil))
+		So(err, ShouldEqual, ErrPageItemsNotList)
+	})
+}
+
+func TestPaginatorVerify(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("token") != "rt" {
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{"code": 10001, "message": "invalid request token"})
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"code": 0,
+			"data": Member{OpenID: "u1", ExpiredAt: time.Now().Unix() + 3600},
+		})
+	}))
+	defer server.Close()
+
+	var fetched int
+	var member Member
+	paginator := NewPaginator("list", func(ctx context.Context, cursor string) (interface{}, string, error) {
+		fetched++
+		member, _ = MemberFromContext(ctx)
+		return []int{1}, "", nil
+	})
+	paginator.Client = NewClient("token", nil, WithAPIHost(server.URL))
+
+	Convey("Request tokens are verified before fetching", t, func() {
+		for _, target := range []string{"/more?_rt=bad&_cid=c1", "/more"} {
+			w := httptest.NewRecorder()
+			paginator.ServeHTTP(w, httptest.NewRequest("GET", target, nil))
+			resp := &Response{}
+			So(json.NewDecoder(w.Body).Decode(resp), ShouldBeNil)
+			So(resp.Dismiss.Type, ShouldEqual, Error)
+			So(resp.Dismiss.Tip, ShouldEqual, paginator.VerifyErrorTip)
+		}
+		So(fetched, ShouldEqual, 0)
+
+		w := httptest.NewRecorder()
+		paginator.ServeHTTP(w, httptest.NewRequest("GET", "/more?_rt=rt&_cid=c1", nil))
+		resp := &Response{}
+		So(json.NewDecoder(w.Body).Decode(resp), ShouldBeNil)
+		So(resp.Dismiss, ShouldBeNil)
+		So(resp.UpdatePart.NoMoreContents, ShouldBeTrue)
+		So(fetched, ShouldEqual, 1)
+		So(member.OpenID, ShouldEqual, "u1")
+	})
+}
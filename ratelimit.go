@@ -0,0 +1,255 @@
+package go_sdk
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	ErrRateLimited = errors.New("rate limit exceeded")
+)
+
+// Endpoint 表示平台接口的分组，不同分组的接口有各自的限流配额
+type Endpoint string
+
+const (
+	EndpointVerify   Endpoint = "verify"
+	EndpointMessages Endpoint = "messages"
+)
+
+// endpointOf 返回接口路径所属的分组，path 不含版本前缀，如 /messages/batch
+func endpointOf(path string) Endpoint {
+	if path == "/user/verify" {
+		return EndpointVerify
+	}
+
+	path = strings.TrimPrefix(path, "/")
+	if i := strings.Index(path, "/"); i >= 0 {
+		path = path[:i]
+	}
+	return Endpoint(path)
+}
+
+// RateLimitMode 表示超出限流配额时的处理方式
+type RateLimitMode int
+
+const (
+	// RateLimitWait 阻塞等待直到有可用的配额，或者请求的 context 被取消
+	RateLimitWait RateLimitMode = iota
+	// RateLimitFailFast 立即返回 ErrRateLimited
+	RateLimitFailFast
+)
+
+// RateLimiter 是一个令牌桶限流器，每秒生成 rate 个令牌，最多积攒 burst 个，rate 为 0 表示不限流
+type RateLimiter struct {
+	mutex       sync.Mutex
+	rate        float64
+	burst       float64
+	tokens      float64
+	last        time.Time
+	pausedUntil time.Time
+}
+
+func NewRateLimiter(rate float64, burst int) *RateLimiter {
+	if burst < 1 {
+		burst = 1
+	}
+
+	return &RateLimiter{
+		rate:   rate,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   time.Now(),
+	}
+}
+
+func (l *RateLimiter) refill(now time.Time) {
+	if now.After(l.last) {
+		l.tokens += now.Sub(l.last).Seconds() * l.rate
+		if l.tokens > l.burst {
+			l.tokens = l.burst
+		}
+		l.last = now
+	}
+}
+
+// Allow 在有可用令牌时消耗一个令牌并返回 true，否则返回 false
+func (l *RateLimiter) Allow() bool {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	now := time.Now()
+	if now.Before(l.pausedUntil) {
+		return false
+	}
+
+	if l.rate <= 0 {
+		return true
+	}
+
+	l.refill(now)
+	if l.tokens < 1 {
+		return false
+	}
+
+	l.tokens--
+	return true
+}
+
+// Wait 阻塞等待直到获得一个令牌，ctx 取消时返回 ctx.Err()
+func (l *RateLimiter) Wait(ctx context.Context) error {
+	l.mutex.Lock()
+	now := time.Now()
+	var delay time.Duration
+	if l.rate > 0 {
+		l.refill(now)
+		l.tokens--
+		if l.tokens < 0 {
+			delay = time.Duration(-l.tokens / l.rate * float64(time.Second))
+		}
+	}
+
+	if pause := l.pausedUntil.Sub(now); pause > delay {
+		delay = pause
+	}
+	l.mutex.Unlock()
+
+	if delay <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		// 归还未使用的令牌
+		if l.rate > 0 {
+			l.mutex.Lock()
+			l.tokens++
+			l.mutex.Unlock()
+		}
+		return ctx.Err()
+	}
+}
+
+// PauseUntil 暂停发放令牌直到 t，用于平台返回限流错误时让后续请求等待
+func (l *RateLimiter) PauseUntil(t time.Time) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	if t.After(l.pausedUntil) {
+		l.pausedUntil = t
+	}
+}
+
+// WithRateLimit 设置某一分组接口的限流配额，每秒最多 rate 次请求，允许瞬时 burst 次请求
+func WithRateLimit(endpoint Endpoint, rate float64, burst int) ClientOption {
+	return func(c *Client) {
+		c.limiters[endpoint] = NewRateLimiter(rate, burst)
+	}
+}
+
+// WithRateLimitMode 设置超出限流配额时的处理方式，默认为 RateLimitWait
+func WithRateLimitMode(mode RateLimitMode) ClientOption {
+	return func(c *Client) {
+		c.rateLimitMode = mode
+	}
+}
+
+// WithRateLimitErrorCodes 设置平台表示请求被限流的 APIError 错误码，收到这些错误码时，
+// 同一分组的后续请求将暂停一段时间。HTTP 429 状态码以及 Retry-After、X-RateLimit-Reset 响应头总是生效
+func WithRateLimitErrorCodes(codes ...int) ClientOption {
+	return func(c *Client) {
+		for _, code := range codes {
+			c.rateLimitCodes[code] = true
+		}
+	}
+}
+
+// defaultRateLimitPause 为平台返回限流错误但没有指明等待时间时的暂停时间
+const defaultRateLimitPause = time.Second
+
+func (c *Client) limiter(endpoint Endpoint, create bool) *RateLimiter {
+	c.limiterMutex.Lock()
+	defer c.limiterMutex.Unlock()
+
+	l, ok := c.limiters[endpoint]
+	if !ok && create {
+		// 未设置配额的分组也需要在平台限流时暂停
+		l = NewRateLimiter(0, 1)
+		c.limiters[endpoint] = l
+	}
+	return l
+}
+
+func (c *Client) waitRateLimit(ctx context.Context, endpoint Endpoint) error {
+	l := c.limiter(endpoint, false)
+	if l == nil {
+		return nil
+	}
+
+	if c.rateLimitMode == RateLimitFailFast {
+		if !l.Allow() {
+			return ErrRateLimited
+		}
+		return nil
+	}
+
+	return l.Wait(ctx)
+}
+
+// adaptRateLimit 根据平台返回的状态码、错误码以及响应头调整限流
+func (c *Client) adaptRateLimit(endpoint Endpoint, resp *http.Response, code int) {
+	now := time.Now()
+	var until time.Time
+
+	if v := resp.Header.Get("Retry-After"); v != "" {
+		if seconds, err := strconv.Atoi(v); err == nil {
+			until = now.Add(time.Duration(seconds) * time.Second)
+		} else if t, err := http.ParseTime(v); err == nil {
+			until = t
+		}
+	}
+
+	if until.IsZero() && resp.Header.Get("X-RateLimit-Remaining") == "0" {
+		if reset, err := strconv.ParseInt(resp.Header.Get("X-RateLimit-Reset"), 10, 64); err == nil {
+			until = time.Unix(reset, 0)
+		}
+	}
+
+	limited := resp.StatusCode == http.StatusTooManyRequests || c.rateLimitCodes[code]
+	if until.IsZero() {
+		if !limited {
+			return
+		}
+		until = now.Add(defaultRateLimitPause)
+	}
+
+	if until.After(now) {
+		c.limiter(endpoint, true).PauseUntil(until)
+	}
+}
+
+// isRateLimitError 返回错误是否为平台或者客户端的限流错误
+func (c *Client) isRateLimitError(err error) bool {
+	switch e := err.(type) {
+	case *APIError:
+		return c.rateLimitCodes[e.Code]
+	case *StatusError:
+		return e.StatusCode == http.StatusTooManyRequests
+	}
+
+	return err == ErrRateLimited
+}
+
+// shouldRetry 返回失败的请求稍后是否值得重试，平台的限流错误码也视为临时性的错误
+func (c *Client) shouldRetry(err error) bool {
+	return c.isRateLimitError(err) || isTemporaryError(err)
+}
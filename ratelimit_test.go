@@ -0,0 +1,73 @@
+package go_sdk
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestRateLimiter(t *testing.T) {
+	Convey("Tokens are limited by burst and refilled by rate", t, func() {
+		l := NewRateLimiter(20, 2)
+		So(l.Allow(), ShouldBeTrue)
+		So(l.Allow(), ShouldBeTrue)
+		So(l.Allow(), ShouldBeFalse)
+
+		start := time.Now()
+		So(l.Wait(context.Background()), ShouldBeNil)
+		So(time.Since(start), ShouldBeGreaterThanOrEqualTo, 40*time.Millisecond)
+	})
+
+	Convey("Waiting is cancelled with the context", t, func() {
+		l := NewRateLimiter(1, 1)
+		So(l.Allow(), ShouldBeTrue)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		err := l.Wait(ctx)
+		So(err != nil && err == ctx.Err(), ShouldBeTrue)
+	})
+
+	Convey("A paused limiter allows nothing", t, func() {
+		l := NewRateLimiter(0, 1)
+		So(l.Allow(), ShouldBeTrue)
+		l.PauseUntil(time.Now().Add(time.Hour))
+		So(l.Allow(), ShouldBeFalse)
+	})
+}
+
+func TestClientRateLimitAdaptation(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "60")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	originHost := apiHost
+	apiHost = server.URL
+	defer func() { apiHost = originHost }()
+
+	Convey("Requests fail fast after the platform responds 429", t, func() {
+		client := NewClient("token", nil, WithRateLimitMode(RateLimitFailFast))
+		err := client.DeleteMessage(1)
+		So(err, ShouldResemble, &StatusError{StatusCode: http.StatusTooManyRequests})
+		So(client.shouldRetry(err), ShouldBeTrue)
+
+		So(client.DeleteMessage(1), ShouldEqual, ErrRateLimited)
+		_, err = client.VerifyRequestToken("rt")
+		So(err, ShouldResemble, &StatusError{StatusCode: http.StatusTooManyRequests})
+	})
+
+	Convey("Endpoints are grouped by API path when the host has a base path", t, func() {
+		client := NewClient("token", nil, WithAPIHost(server.URL+"/gateway"), WithRateLimitMode(RateLimitFailFast))
+		So(client.DeleteMessage(1), ShouldResemble, &StatusError{StatusCode: http.StatusTooManyRequests})
+
+		So(client.DeleteMessage(1), ShouldEqual, ErrRateLimited)
+		_, err := client.VerifyRequestToken("rt")
+		So(err, ShouldResemble, &StatusError{StatusCode: http.StatusTooManyRequests})
+	})
+}
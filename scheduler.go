@@ -0,0 +1,492 @@
+package go_sdk
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	ErrScheduleIDRequired = errors.New("schedule id is required")
+	ErrScheduleNotFound   = errors.New("schedule not found")
+)
+
+// Schedule 为一条定时推送的消息
+type Schedule struct {
+	// 开发者自定义的 ID，比如待办事项的 ID，用于取消和修改定时推送
+	ID      string                `json:"id"`
+	Request *CreateMessageRequest `json:"request"`
+
+	// 下次推送的时间
+	At time.Time `json:"at"`
+	// 重复推送的 cron 规则，为空则只推送一次，参考 ParseCron
+	Cron string `json:"cron,omitempty"`
+
+	// 已推送的次数、本次推送已失败的次数以及最后一次推送失败的原因
+	Runs      int    `json:"runs"`
+	Attempts  int    `json:"attempts,omitempty"`
+	LastError string `json:"lastError,omitempty"`
+}
+
+// ScheduleStore 定义了一套用来持久化定时推送的接口，SDK 实现了基于内存和文件的存储
+type ScheduleStore interface {
+	// Save 保存一条新的或者覆盖已有的定时推送
+	Save(s *Schedule) error
+	// Get 获取定时推送，不存在时返回 ErrScheduleNotFound
+	Get(id string) (*Schedule, error)
+	Delete(id string) error
+	// Due 返回 At 不晚于 now 的定时推送
+	Due(now time.Time) ([]*Schedule, error)
+}
+
+// Scheduler 在指定的时间推送消息，比如待办事项到期时提醒用户，定时推送保存在 ScheduleStore 中，
+// 服务重启后继续生效，重启期间错过的推送在启动后立即补发：
+//      scheduler := NewScheduler(client, NewFileScheduleStore("/var/lib/app/schedules.json"))
+//      scheduler.Start()
+//      defer scheduler.Stop()
+//
+//      err := scheduler.ScheduleAt("todo-42", todo.DueAt, &CreateMessageRequest{...})
+type Scheduler struct {
+	client *Client
+	store  ScheduleStore
+	outbox *Outbox
+
+	pollInterval  time.Duration
+	retryInterval time.Duration
+	maxAttempts   int
+	onSent        func(s *Schedule, messageID int64)
+	onError       func(s *Schedule, err error)
+
+	mutex    sync.Mutex
+	started  bool
+	stopOnce sync.Once
+	stop     chan struct{}
+	stopped  chan struct{}
+}
+
+// SchedulerOption 用于设置 Scheduler 的可选参数
+type SchedulerOption func(s *Scheduler)
+
+// WithSchedulerPollInterval 设置检查到期推送的时间间隔，默认为 1 秒
+func WithSchedulerPollInterval(d time.Duration) SchedulerOption {
+	return func(s *Scheduler) {
+		s.pollInterval = d
+	}
+}
+
+// WithSchedulerRetryInterval 设置推送因临时性错误失败后的重试间隔，默认为 1 分钟
+func WithSchedulerRetryInterval(d time.Duration) SchedulerOption {
+	return func(s *Scheduler) {
+		s.retryInterval = d
+	}
+}
+
+// WithSchedulerMaxAttempts 设置单次推送的最大尝试次数，默认为 10 次，用尽后按业务性错误处理
+func WithSchedulerMaxAttempts(n int) SchedulerOption {
+	return func(s *Scheduler) {
+		s.maxAttempts = n
+	}
+}
+
+// WithSchedulerOutbox 设置到期的推送写入发件箱，由发件箱负责投递和重试，此时 WithSchedulerSent 回调中 messageID 为 0
+func WithSchedulerOutbox(outbox *Outbox) SchedulerOption {
+	return func(s *Scheduler) {
+		s.outbox = outbox
+	}
+}
+
+// WithSchedulerSent 设置推送成功的回调
+func WithSchedulerSent(fn func(s *Schedule, messageID int64)) SchedulerOption {
+	return func(s *Scheduler) {
+		s.onSent = fn
+	}
+}
+
+// WithSchedulerError 设置推送因业务性错误失败或者重试次数用尽的回调，失败的单次推送将被删除，重复推送则继续下一次
+func WithSchedulerError(fn func(s *Schedule, err error)) SchedulerOption {
+	return func(s *Scheduler) {
+		s.onError = fn
+	}
+}
+
+func NewScheduler(client *Client, store ScheduleStore, opts ...SchedulerOption) *Scheduler {
+	s := &Scheduler{
+		client:        client,
+		store:         store,
+		pollInterval:  time.Second,
+		retryInterval: time.Minute,
+		maxAttempts:   10,
+		stop:          make(chan struct{}),
+		stopped:       make(chan struct{}),
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// ScheduleAt 在 at 时推送消息，id 已存在时覆盖原有的定时推送
+func (s *Scheduler) ScheduleAt(id string, at time.Time, cmr *CreateMessageRequest) error {
+	if id == "" {
+		return ErrScheduleIDRequired
+	}
+
+	if err := cmr.check(); err != nil {
+		return err
+	}
+
+	return s.save(&Schedule{ID: id, Request: cmr, At: at})
+}
+
+// ScheduleCron 按 cron 规则重复推送消息，id 已存在时覆盖原有的定时推送
+func (s *Scheduler) ScheduleCron(id, spec string, cmr *CreateMessageRequest) error {
+	if id == "" {
+		return ErrScheduleIDRequired
+	}
+
+	if err := cmr.check(); err != nil {
+		return err
+	}
+
+	c, err := ParseCron(spec)
+	if err != nil {
+		return err
+	}
+
+	return s.save(&Schedule{ID: id, Request: cmr, At: c.Next(time.Now()), Cron: spec})
+}
+
+// Reschedule 修改下次推送的时间
+func (s *Scheduler) Reschedule(id string, at time.Time) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	sc, err := s.store.Get(id)
+	if err != nil {
+		return err
+	}
+
+	sc.At = at
+	return s.store.Save(sc)
+}
+
+// Cancel 取消定时推送
+func (s *Scheduler) Cancel(id string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return s.store.Delete(id)
+}
+
+func (s *Scheduler) save(sc *Schedule) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return s.store.Save(sc)
+}
+
+// Start 启动定时推送，重复调用不会有任何效果
+func (s *Scheduler) Start() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.started {
+		return
+	}
+
+	s.started = true
+	go s.run()
+}
+
+// Stop 停止定时推送，等待正在进行的推送完成后返回。没有调用过 Start 或者重复调用时直接返回
+func (s *Scheduler) Stop() {
+	s.stopOnce.Do(func() { close(s.stop) })
+
+	s.mutex.Lock()
+	started := s.started
+	s.mutex.Unlock()
+	if started {
+		<-s.stopped
+	}
+}
+
+func (s *Scheduler) run() {
+	defer close(s.stopped)
+
+	ticker := time.NewTicker(s.pollInterval)
+	defer ticker.Stop()
+	for {
+		s.runDue()
+
+		select {
+		case <-s.stop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *Scheduler) runDue() {
+	now := time.Now()
+	due, err := s.store.Due(now)
+	if err != nil {
+		s.client.log().Error("failed to list due schedules", "error", err.Error())
+		return
+	}
+
+	for _, sc := range due {
+		select {
+		case <-s.stop:
+			return
+		default:
+		}
+
+		s.send(sc, now)
+	}
+}
+
+func (s *Scheduler) send(sc *Schedule, now time.Time) {
+	var messageID int64
+	var err error
+	if s.outbox != nil {
+		_, err = s.outbox.Create(sc.Request)
+	} else {
+		messageID, err = s.client.CreateMessageContext(context.Background(), sc.Request)
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	// 推送期间定时推送可能被取消或修改
+	current, gerr := s.store.Get(sc.ID)
+	if gerr != nil {
+		if gerr != ErrScheduleNotFound {
+			s.client.log().Error("failed to get schedule", "id", sc.ID, "error", gerr.Error())
+		}
+		return
+	}
+	if !current.At.Equal(sc.At) {
+		return
+	}
+
+	if err != nil {
+		sc.Attempts++
+		sc.LastError = err.Error()
+	}
+
+	if err != nil && s.client.shouldRetry(err) && sc.Attempts < s.maxAttempts {
+		sc.At = now.Add(s.retryInterval)
+		s.client.log().Warn("scheduled message will be retried", "id", sc.ID, "attempts", sc.Attempts, "at", sc.At, "error", sc.LastError)
+		s.saveLocked(sc)
+		return
+	}
+
+	sc.Attempts = 0
+	if err != nil {
+		s.client.log().Error("failed to send scheduled message", "id", sc.ID, "error", sc.LastError)
+		if s.onError != nil {
+			s.onError(sc, err)
+		}
+	} else {
+		sc.Runs++
+		sc.LastError = ""
+		if s.onSent != nil {
+			s.onSent(sc, messageID)
+		}
+	}
+
+	if sc.Cron != "" {
+		if c, cerr := ParseCron(sc.Cron); cerr == nil {
+			if next := c.Next(now); !next.IsZero() {
+				sc.At = next
+				s.saveLocked(sc)
+				return
+			}
+		}
+	}
+
+	if err := s.store.Delete(sc.ID); err != nil {
+		s.client.log().Error("failed to delete schedule", "id", sc.ID, "error", err.Error())
+	}
+}
+
+// saveLocked 保存推送后的状态，调用方需持有 s.mutex
+func (s *Scheduler) saveLocked(sc *Schedule) {
+	if err := s.store.Save(sc); err != nil {
+		s.client.log().Error("failed to save schedule", "id", sc.ID, "error", err.Error())
+	}
+}
+
+// MemoryScheduleStore 实现了基于内存的 ScheduleStore 接口，服务重启后定时推送将丢失，适用于开发和测试
+type MemoryScheduleStore struct {
+	mutex     sync.Mutex
+	schedules map[string]*Schedule
+}
+
+func NewMemoryScheduleStore() *MemoryScheduleStore {
+	return &MemoryScheduleStore{
+		schedules: make(map[string]*Schedule),
+	}
+}
+
+func (m *MemoryScheduleStore) Save(s *Schedule) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	c := *s
+	m.schedules[s.ID] = &c
+	return nil
+}
+
+func (m *MemoryScheduleStore) Get(id string) (*Schedule, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	s, ok := m.schedules[id]
+	if !ok {
+		return nil, ErrScheduleNotFound
+	}
+
+	c := *s
+	return &c, nil
+}
+
+func (m *MemoryScheduleStore) Delete(id string) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	delete(m.schedules, id)
+	return nil
+}
+
+func (m *MemoryScheduleStore) Due(now time.Time) ([]*Schedule, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	return dueSchedules(m.schedules, now), nil
+}
+
+func dueSchedules(schedules map[string]*Schedule, now time.Time) []*Schedule {
+	var due []*Schedule
+	for _, s := range schedules {
+		if !s.At.After(now) {
+			c := *s
+			due = append(due, &c)
+		}
+	}
+
+	sort.Slice(due, func(i, j int) bool { return due[i].At.Before(due[j].At) })
+	return due
+}
+
+// FileScheduleStore 实现了基于文件的 ScheduleStore 接口，所有定时推送保存在同一个 JSON 文件中，
+// 适用于单实例部署、定时推送数量不多的场景
+type FileScheduleStore struct {
+	filename string
+	mutex    sync.Mutex
+}
+
+func NewFileScheduleStore(filename string) *FileScheduleStore {
+	return &FileScheduleStore{filename: filename}
+}
+
+func (f *FileScheduleStore) load() (map[string]*Schedule, error) {
+	schedules := make(map[string]*Schedule)
+	b, err := ioutil.ReadFile(f.filename)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return schedules, nil
+		}
+		return nil, err
+	}
+
+	if len(strings.TrimSpace(string(b))) == 0 {
+		return schedules, nil
+	}
+
+	return schedules, json.Unmarshal(b, &schedules)
+}
+
+// flush 先写入临时文件再重命名，避免进程中断时留下不完整的文件
+func (f *FileScheduleStore) flush(schedules map[string]*Schedule) error {
+	b, err := json.MarshalIndent(schedules, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err = os.MkdirAll(filepath.Dir(f.filename), 0755); err != nil {
+		return err
+	}
+
+	tmp := f.filename + ".tmp"
+	if err = ioutil.WriteFile(tmp, b, 0644); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, f.filename)
+}
+
+func (f *FileScheduleStore) Save(s *Schedule) error {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	schedules, err := f.load()
+	if err != nil {
+		return err
+	}
+
+	schedules[s.ID] = s
+	return f.flush(schedules)
+}
+
+func (f *FileScheduleStore) Get(id string) (*Schedule, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	schedules, err := f.load()
+	if err != nil {
+		return nil, err
+	}
+
+	s, ok := schedules[id]
+	if !ok {
+		return nil, ErrScheduleNotFound
+	}
+	return s, nil
+}
+
+func (f *FileScheduleStore) Delete(id string) error {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	schedules, err := f.load()
+	if err != nil {
+		return err
+	}
+
+	if _, ok := schedules[id]; !ok {
+		return nil
+	}
+
+	delete(schedules, id)
+	return f.flush(schedules)
+}
+
+func (f *FileScheduleStore) Due(now time.Time) ([]*Schedule, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	schedules, err := f.load()
+	if err != nil {
+		return nil, err
+	}
+
+	return dueSchedules(schedules, now), nil
+}
@@ -0,0 +1,207 @@
+package go_sdk
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func newScheduledRequest(title string) *CreateMessageRequest {
+	return &CreateMessageRequest{
+		ToAll:                 true,
+		MessageContentRequest: MessageContentRequest{TemplateID: "t1", TemplateVersion: 1, Title: title},
+	}
+}
+
+type failingScheduleStore struct {
+	ScheduleStore
+}
+
+func (failingScheduleStore) Due(now time.Time) ([]*Schedule, error) {
+	return nil, errors.New("store unavailable")
+}
+
+func TestScheduler(t *testing.T) {
+	var failures int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt64(&failures, -1) >= 0 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"code": 0, "data": map[string]interface{}{"id": 9}})
+	}))
+	defer server.Close()
+
+	newScheduler := func(store ScheduleStore, sent chan *Schedule) *Scheduler {
+		return NewScheduler(NewClient("token", nil, WithAPIHost(server.URL)), store,
+			WithSchedulerPollInterval(5*time.Millisecond),
+			WithSchedulerRetryInterval(5*time.Millisecond),
+			WithSchedulerSent(func(s *Schedule, messageID int64) {
+				sent <- s
+			}))
+	}
+
+	waitSent := func(sent chan *Schedule) *Schedule {
+		select {
+		case s := <-sent:
+			return s
+		case <-time.After(5 * time.Second):
+			t.Fatal("scheduled message not sent in time")
+			return nil
+		}
+	}
+
+	Convey("Send due schedules, skipping cancelled ones", t, func() {
+		atomic.StoreInt64(&failures, 0)
+		store := NewMemoryScheduleStore()
+		sent := make(chan *Schedule, 10)
+		scheduler := newScheduler(store, sent)
+
+		now := time.Now()
+		So(scheduler.ScheduleAt("", now, newScheduledRequest("x")), ShouldEqual, ErrScheduleIDRequired)
+		So(scheduler.ScheduleAt("cancelled", now.Add(-2*time.Second), newScheduledRequest("cancelled")), ShouldBeNil)
+		So(scheduler.ScheduleAt("due", now.Add(-time.Second), newScheduledRequest("due")), ShouldBeNil)
+		So(scheduler.ScheduleAt("later", now.Add(time.Hour), newScheduledRequest("later")), ShouldBeNil)
+		So(scheduler.Cancel("cancelled"), ShouldBeNil)
+
+		scheduler.Start()
+		s := waitSent(sent)
+		So(s.ID, ShouldEqual, "due")
+		So(s.Runs, ShouldEqual, 1)
+
+		So(scheduler.Reschedule("later", time.Now()), ShouldBeNil)
+		So(waitSent(sent).ID, ShouldEqual, "later")
+		scheduler.Stop()
+
+		So(len(sent), ShouldEqual, 0)
+		_, err := store.Get("due")
+		So(err, ShouldEqual, ErrScheduleNotFound)
+		So(scheduler.Reschedule("missing", now), ShouldEqual, ErrScheduleNotFound)
+	})
+
+	Convey("Retry temporary failures", t, func() {
+		atomic.StoreInt64(&failures, 2)
+		store := NewMemoryScheduleStore()
+		sent := make(chan *Schedule, 10)
+		scheduler := newScheduler(store, sent)
+
+		So(scheduler.ScheduleAt("flaky", time.Now(), newScheduledRequest("flaky")), ShouldBeNil)
+		scheduler.Start()
+		defer scheduler.Stop()
+
+		s := waitSent(sent)
+		So(s.ID, ShouldEqual, "flaky")
+		So(s.LastError, ShouldBeEmpty)
+		So(atomic.LoadInt64(&failures), ShouldBeLessThan, 0)
+	})
+
+	Convey("Give up after max attempts", t, func() {
+		atomic.StoreInt64(&failures, 100)
+		store := NewMemoryScheduleStore()
+		failed := make(chan error, 1)
+		scheduler := NewScheduler(NewClient("token", nil, WithAPIHost(server.URL)), store,
+			WithSchedulerPollInterval(5*time.Millisecond),
+			WithSchedulerRetryInterval(5*time.Millisecond),
+			WithSchedulerMaxAttempts(3),
+			WithSchedulerError(func(s *Schedule, err error) {
+				failed <- err
+			}))
+
+		So(scheduler.ScheduleAt("broken", time.Now(), newScheduledRequest("broken")), ShouldBeNil)
+		scheduler.Start()
+
+		select {
+		case err := <-failed:
+			So(isTemporaryError(err), ShouldBeTrue)
+		case <-time.After(5 * time.Second):
+			t.Fatal("schedule not given up in time")
+		}
+		scheduler.Stop()
+
+		So(atomic.LoadInt64(&failures), ShouldEqual, 97)
+		_, err := store.Get("broken")
+		So(err, ShouldEqual, ErrScheduleNotFound)
+	})
+
+	Convey("Report store errors through the client logger", t, func() {
+		rl := &recordLogger{}
+		scheduler := NewScheduler(NewClient("token", nil, WithAPIHost(server.URL), WithLogger(rl.Logger())), failingScheduleStore{})
+		scheduler.runDue()
+
+		entries := rl.Entries()
+		So(entries, ShouldHaveLength, 1)
+		So(entries[0].level, ShouldEqual, LevelError)
+		So(entries[0].msg, ShouldEqual, "failed to list due schedules")
+		So(entries[0].fields["error"], ShouldEqual, "store unavailable")
+	})
+
+	Convey("Keep cron schedules after sending", t, func() {
+		atomic.StoreInt64(&failures, 0)
+		store := NewMemoryScheduleStore()
+		sent := make(chan *Schedule, 10)
+		scheduler := newScheduler(store, sent)
+
+		So(scheduler.ScheduleCron("daily", "0 9 * * *", newScheduledRequest("daily")), ShouldBeNil)
+		So(scheduler.Reschedule("daily", time.Now()), ShouldBeNil)
+		scheduler.Start()
+		So(waitSent(sent).ID, ShouldEqual, "daily")
+		scheduler.Stop()
+
+		s, err := store.Get("daily")
+		So(err, ShouldBeNil)
+		So(s.Runs, ShouldEqual, 1)
+		So(s.At.After(time.Now()), ShouldBeTrue)
+	})
+
+	Convey("Stop without Start and repeated Stop return", t, func() {
+		scheduler := newScheduler(NewMemoryScheduleStore(), make(chan *Schedule, 1))
+		scheduler.Stop()
+		scheduler.Stop()
+
+		scheduler = newScheduler(NewMemoryScheduleStore(), make(chan *Schedule, 1))
+		scheduler.Start()
+		scheduler.Start()
+		scheduler.Stop()
+		scheduler.Stop()
+	})
+}
+
+func TestFileScheduleStore(t *testing.T) {
+	Convey("Persist schedules across reloads", t, func() {
+		filename := filepath.Join(t.TempDir(), "sub", "schedules.json")
+		store := NewFileScheduleStore(filename)
+
+		due, err := store.Due(time.Now())
+		So(err, ShouldBeNil)
+		So(due, ShouldBeEmpty)
+
+		at := time.Unix(1000, 0).UTC()
+		So(store.Save(&Schedule{ID: "a", Request: newScheduledRequest("a"), At: at}), ShouldBeNil)
+		So(store.Save(&Schedule{ID: "b", Request: newScheduledRequest("b"), At: at.Add(-time.Minute), Cron: "0 9 * * *"}), ShouldBeNil)
+		So(store.Save(&Schedule{ID: "c", Request: newScheduledRequest("c"), At: at.Add(time.Hour)}), ShouldBeNil)
+
+		store = NewFileScheduleStore(filename)
+		s, err := store.Get("b")
+		So(err, ShouldBeNil)
+		So(s.Cron, ShouldEqual, "0 9 * * *")
+		So(s.Request.Title, ShouldEqual, "b")
+
+		due, err = store.Due(at)
+		So(err, ShouldBeNil)
+		So(len(due), ShouldEqual, 2)
+		So(due[0].ID, ShouldEqual, "b")
+		So(due[1].ID, ShouldEqual, "a")
+
+		So(store.Delete("a"), ShouldBeNil)
+		So(store.Delete("a"), ShouldBeNil)
+		_, err = NewFileScheduleStore(filename).Get("a")
+		So(err, ShouldEqual, ErrScheduleNotFound)
+	})
+}
@@ -18,6 +18,8 @@ type QueryParameter struct {
 	MessageLocalID  int64
 	TemplateID      string
 	TemplateVersion int
+	// 客户端支持的 Response 协议版本，旧版本客户端不传，为 0
+	ResponseVersion int
 }
 
 // QueryParameterFrom 是个辅助函数，用于从 http.Request.URL.Query 中获取请求参数
@@ -64,9 +66,22 @@ func QueryParameterFrom(r *http.Request) (q *QueryParameter, err error) {
 		}
 	}
 
+	responseVersion := query.Get("_rv")
+	if responseVersion != "" {
+		q.ResponseVersion, err = strconv.Atoi(responseVersion)
+		if err != nil {
+			return nil, errors.New("invalid rv value")
+		}
+	}
+
 	return
 }
 
+// SupportsActions 返回客户端是否支持 Response.Actions，不支持时只能使用单个的 New、Update、Delete、UpdatePart
+func (q *QueryParameter) SupportsActions() bool {
+	return q.ResponseVersion >= ResponseVersionActions
+}
+
 // CheckForMessageRequest 检查从【消息卡片】操作时发起的请求中，必传参数是否有效
 func (q *QueryParameter) CheckForMessageRequest() error {
 	if q.MessageID < 0 {
@@ -124,12 +139,78 @@ type NewMessage struct {
 	Data            interface{} `json:"data,omitempty"`
 }
 
+// UpdatePartMessage 对指定消息执行局部更新
+type UpdatePartMessage struct {
+	ID      int64 `json:"id,omitempty"`
+	LocalID int64 `json:"localID,omitempty"`
+	*UpdatePart
+}
+
+// Action 为 Response.Actions 中的一个操作，只能设置其中一个字段
+type Action struct {
+	Delete     *DeleteMessage     `json:"delete,omitempty"`
+	UpdatePart *UpdatePartMessage `json:"updatePart,omitempty"`
+	Update     *UpdateMessage     `json:"update,omitempty"`
+	New        *NewMessage        `json:"new,omitempty"`
+}
+
+// valid 返回是否只设置了一个字段，局部更新必须带有 UpdatePart
+func (a *Action) valid() bool {
+	if a.UpdatePart != nil && a.UpdatePart.UpdatePart == nil {
+		return false
+	}
+
+	n := 0
+	for _, set := range []bool{a.Delete != nil, a.UpdatePart != nil, a.Update != nil, a.New != nil} {
+		if set {
+			n++
+		}
+	}
+	return n == 1
+}
+
+func (a *Action) kind() string {
+	switch {
+	case a.Delete != nil:
+		return "delete"
+	case a.Update != nil:
+		return "update"
+	case a.UpdatePart != nil:
+		return "updatePart"
+	case a.New != nil:
+		return "new"
+	}
+	return ""
+}
+
+// Response 协议版本
+const (
+	// 只支持单个的 Delete、UpdatePart、Update、New
+	ResponseVersionSingle = 0
+	// 支持 Actions
+	ResponseVersionActions = 1
+)
+
+var (
+	ErrActionsNotSupported = errors.New("actions require response version 1, create the response with NewResponseFor")
+	ErrInvalidAction       = errors.New("an action must set exactly one of delete, updatePart, update and new")
+)
+
+// Response 为客户端请求的响应。客户端先执行 Delete、Update、UpdatePart、New，UpdatePart 作用于发起请求的消息；
+// Version 为 ResponseVersionActions 时再按顺序执行 Actions，某个操作失败（比如消息已被删除）不影响后面的操作。
+// 使用 Actions 需要通过 NewResponseFor 按客户端支持的版本创建响应，并通过 QueryParameter.SupportsActions 确认客户端支持：
+//      response := go_sdk.NewResponseFor(q).UpdateThisMessage(q, "订单", order)
+//      if q.SupportsActions() {
+//          response.AddUpdatePart(0, summaryLocalID, ops).AddNew(&go_sdk.NewMessage{...})
+//      }
+//      _ = response.Output(w)
 type Response struct {
 	Delete     *DeleteMessage `json:"delete,omitempty"`
 	UpdatePart *UpdatePart    `json:"updatePart,omitempty"`
 	Update     *UpdateMessage `json:"update,omitempty"`
 	New        *NewMessage    `json:"new,omitempty"`
 	Dismiss    *Dismiss       `json:"dismiss,omitempty"`
+	Actions    []Action       `json:"actions,omitempty"`
 	Version    int            `json:"version"`
 }
 
@@ -137,6 +218,14 @@ func NewResponse() *Response {
 	return &Response{}
 }
 
+// NewResponseFor 按请求中客户端支持的协议版本创建响应
+func NewResponseFor(q *QueryParameter) *Response {
+	if q.SupportsActions() {
+		return &Response{Version: ResponseVersionActions}
+	}
+	return &Response{Version: ResponseVersionSingle}
+}
+
 // DeleteThisMessage 删除消息，请求从哪条消息触发的则删除哪条消息，
 // 如果需要删除其它消息，请自行填充相关字段
 func (m *Response) DeleteThisMessage(q *QueryParameter) *Response {
@@ -174,6 +263,32 @@ func (m *Response) UpdateThisMessageWithTemplate(q *QueryParameter, templateID s
 	return m
 }
 
+// AddAction 在 Actions 末尾添加一个操作，a 只能设置一个字段，否则 Output 返回 ErrInvalidAction
+func (m *Response) AddAction(a Action) *Response {
+	m.Actions = append(m.Actions, a)
+	return m
+}
+
+// AddDelete 添加删除消息的操作，id 和 localID 指定一个即可
+func (m *Response) AddDelete(id, localID int64) *Response {
+	return m.AddAction(Action{Delete: &DeleteMessage{ID: id, LocalID: localID}})
+}
+
+// AddUpdate 添加更新消息的操作，通过 u.ID 或 u.LocalID 指定消息
+func (m *Response) AddUpdate(u *UpdateMessage) *Response {
+	return m.AddAction(Action{Update: u})
+}
+
+// AddUpdatePart 添加局部更新消息的操作，id 和 localID 指定一个即可，updatePart 为 nil 时 Output 返回 ErrInvalidAction
+func (m *Response) AddUpdatePart(id, localID int64, updatePart *UpdatePart) *Response {
+	return m.AddAction(Action{UpdatePart: &UpdatePartMessage{ID: id, LocalID: localID, UpdatePart: updatePart}})
+}
+
+// AddNew 添加在客户端生成一条新消息的操作
+func (m *Response) AddNew(n *NewMessage) *Response {
+	return m.AddAction(Action{New: n})
+}
+
 const DismissDuration = 1500
 
 func (m *Response) ShowInfo(tip string) *Response {
@@ -205,10 +320,71 @@ func (m *Response) ShowTip(t TipType, tip string, duration int) *Response {
 	return m
 }
 
-// Output 将数据编码输出，此后不能再输出其它内容
+// Output 将数据编码输出，此后不能再输出其它内容。
+// 包含 Actions 而 Version 低于 ResponseVersionActions 时返回 ErrActionsNotSupported，
+// 某个 Action 没有设置或者设置了多个字段、局部更新的 UpdatePart 为 nil 时返回 ErrInvalidAction，此时不会输出任何内容
 func (m *Response) Output(w http.ResponseWriter) error {
+	if err := m.check(); err != nil {
+		getLogger().Error("invalid response", "version", m.Version, "error", err.Error())
+		return err
+	}
+
 	w.WriteHeader(http.StatusOK)
-	return json.NewEncoder(w).Encode(m)
+	err := json.NewEncoder(w).Encode(m)
+
+	kinds := m.kinds()
+	getMetrics().ObserveResponse(kinds)
+
+	args := []interface{}{"kinds", kinds, "version", m.Version}
+	if m.Dismiss != nil {
+		args = append(args, "tip", m.Dismiss.Tip)
+	}
+	if err != nil {
+		getLogger().Error("failed to write response", append(args, "error", err.Error())...)
+	} else {
+		getLogger().Debug("response written", args...)
+	}
+	return err
+}
+
+func (m *Response) check() error {
+	if len(m.Actions) == 0 {
+		return nil
+	}
+
+	// 旧版本的客户端会忽略 Actions
+	if m.Version < ResponseVersionActions {
+		return ErrActionsNotSupported
+	}
+
+	for i := range m.Actions {
+		if !m.Actions[i].valid() {
+			return ErrInvalidAction
+		}
+	}
+	return nil
+}
+
+// kinds 返回响应中包含的操作类型，包括 Actions 中的操作，每种类型只出现一次
+func (m *Response) kinds() []string {
+	present := map[string]bool{
+		"delete":     m.Delete != nil,
+		"update":     m.Update != nil,
+		"updatePart": m.UpdatePart != nil,
+		"new":        m.New != nil,
+		"dismiss":    m.Dismiss != nil,
+	}
+	for i := range m.Actions {
+		present[m.Actions[i].kind()] = true
+	}
+
+	var kinds []string
+	for _, kind := range []string{"delete", "update", "updatePart", "new", "dismiss"} {
+		if present[kind] {
+			kinds = append(kinds, kind)
+		}
+	}
+	return kinds
 }
 
 // ShowInfo 是 *Response.ShowInfo 方法快捷方式，用于直接输出一个 Dismiss 普通提示信息，
@@ -0,0 +1,92 @@
+package go_sdk
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestResponseActions(t *testing.T) {
+	Convey("Negotiate the response version from the request", t, func() {
+		q, err := QueryParameterFrom(httptest.NewRequest("GET", "/?_rt=rt&_cid=c1", nil))
+		So(err, ShouldBeNil)
+		So(q.SupportsActions(), ShouldBeFalse)
+
+		q, err = QueryParameterFrom(httptest.NewRequest("GET", "/?_rt=rt&_cid=c1&_rv=1", nil))
+		So(err, ShouldBeNil)
+		So(q.SupportsActions(), ShouldBeTrue)
+	})
+
+	Convey("Output actions in order", t, func() {
+		ops := NewUpdatePart()
+		ops.AddOpSet(NewSet().Add("count", 2))
+
+		q, _ := QueryParameterFrom(httptest.NewRequest("GET", "/?_rt=rt&_cid=c1&_rv=1", nil))
+		response := NewResponseFor(q).
+			ShowSuccess("ok").
+			AddUpdatePart(0, 3, ops).
+			AddNew(&NewMessage{TemplateID: "t1", TemplateVersion: 1, Title: "a"}).
+			AddNew(&NewMessage{TemplateID: "t1", TemplateVersion: 1, Title: "b"}).
+			AddDelete(5, 0)
+		So(response.kinds(), ShouldResemble, []string{"delete", "updatePart", "new", "dismiss"})
+
+		w := httptest.NewRecorder()
+		So(response.Output(w), ShouldBeNil)
+
+		decoded := &Response{}
+		So(json.NewDecoder(w.Body).Decode(decoded), ShouldBeNil)
+		So(decoded.Version, ShouldEqual, ResponseVersionActions)
+		So(decoded.Actions, ShouldHaveLength, 4)
+		So(decoded.Actions[0].UpdatePart.LocalID, ShouldEqual, 3)
+		So(decoded.Actions[0].UpdatePart.Ops, ShouldHaveLength, 1)
+		So(decoded.Actions[2].New.Title, ShouldEqual, "b")
+		So(decoded.Actions[3].Delete.ID, ShouldEqual, 5)
+	})
+
+	Convey("Single-action responses keep version 0", t, func() {
+		w := httptest.NewRecorder()
+		So(NewResponse().ShowInfo("hi").Output(w), ShouldBeNil)
+		decoded := &Response{}
+		So(json.NewDecoder(w.Body).Decode(decoded), ShouldBeNil)
+		So(decoded.Version, ShouldEqual, ResponseVersionSingle)
+		So(decoded.Actions, ShouldBeEmpty)
+	})
+
+	Convey("Actions are rejected unless the client negotiated them", t, func() {
+		q, _ := QueryParameterFrom(httptest.NewRequest("GET", "/?_rt=rt&_cid=c1", nil))
+		response := NewResponseFor(q).AddDelete(5, 0)
+		So(response.Version, ShouldEqual, ResponseVersionSingle)
+
+		w := httptest.NewRecorder()
+		So(response.Output(w), ShouldEqual, ErrActionsNotSupported)
+		So(w.Body.Len(), ShouldEqual, 0)
+
+		w = httptest.NewRecorder()
+		So(NewResponse().AddNew(&NewMessage{Title: "a"}).Output(w), ShouldEqual, ErrActionsNotSupported)
+		So(w.Body.Len(), ShouldEqual, 0)
+	})
+
+	Convey("Each action sets exactly one field", t, func() {
+		q, _ := QueryParameterFrom(httptest.NewRequest("GET", "/?_rt=rt&_cid=c1&_rv=1", nil))
+
+		response := NewResponseFor(q).AddAction(Action{
+			Delete: &DeleteMessage{ID: 5},
+			Update: &UpdateMessage{ID: 5, Title: "a"},
+			New:    &NewMessage{Title: "b"},
+		})
+		w := httptest.NewRecorder()
+		So(response.Output(w), ShouldEqual, ErrInvalidAction)
+		So(w.Body.Len(), ShouldEqual, 0)
+
+		response = NewResponseFor(q).AddDelete(5, 0).AddAction(Action{})
+		So(response.Output(httptest.NewRecorder()), ShouldEqual, ErrInvalidAction)
+
+		w = httptest.NewRecorder()
+		So(NewResponseFor(q).AddUpdatePart(5, 0, nil).Output(w), ShouldEqual, ErrInvalidAction)
+		So(w.Body.Len(), ShouldEqual, 0)
+		So(NewResponseFor(q).AddAction(Action{UpdatePart: &UpdatePartMessage{ID: 5}}).Output(httptest.NewRecorder()), ShouldEqual, ErrInvalidAction)
+		So(NewResponseFor(q).AddUpdatePart(5, 0, NewUpdatePart()).Output(httptest.NewRecorder()), ShouldBeNil)
+	})
+}
@@ -0,0 +1,63 @@
+package smotel
+
+import (
+	"net/http"
+
+	go_sdk "github.com/super-message/go-sdk"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Middleware 使用 otel 全局的 TracerProvider 为 App 客户端发起的每个请求生成一个 server span，
+// 可以直接用于 gorilla/mux 的 router.Use
+func Middleware(next http.Handler) http.Handler {
+	return NewMiddleware(nil)(next)
+}
+
+// NewMiddleware 与 Middleware 相同，tp 为 nil 时使用 otel.GetTracerProvider()。
+// 请求头中带有链路信息时（比如经过了已接入 OpenTelemetry 的网关）沿用该链路，
+// span 中记录了请求来自的频道、消息以及模板版本，request token 不会被记录
+func NewMiddleware(tp trace.TracerProvider) func(http.Handler) http.Handler {
+	if tp == nil {
+		tp = otel.GetTracerProvider()
+	}
+	tracer := tp.Tracer(instrumentationName)
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+
+			attrs := map[string]interface{}{
+				go_sdk.AttrHTTPMethod: r.Method,
+				go_sdk.AttrHTTPPath:   r.URL.Path,
+			}
+			if q, err := go_sdk.QueryParameterFrom(r); err == nil {
+				attrs[go_sdk.AttrChannelID] = q.ChannelID
+				attrs[go_sdk.AttrMessageID] = q.MessageID
+				attrs[go_sdk.AttrTemplateID] = q.TemplateID
+				attrs[go_sdk.AttrTemplateVersion] = q.TemplateVersion
+			}
+
+			ctx, span := tracer.Start(ctx, r.Method+" "+r.URL.Path,
+				trace.WithSpanKind(trace.SpanKindServer),
+				trace.WithAttributes(attributes(attrs)...))
+			defer span.End()
+
+			sw := &statusWriter{ResponseWriter: w, status: http.StatusOK}
+			next.ServeHTTP(sw, r.WithContext(ctx))
+
+			span.SetAttributes(attributes(map[string]interface{}{go_sdk.AttrHTTPStatus: sw.status})...)
+		})
+	}
+}
+
+type statusWriter struct {
+	http.ResponseWriter
+	status int
+}
+
+func (w *statusWriter) WriteHeader(status int) {
+	w.status = status
+	w.ResponseWriter.WriteHeader(status)
+}
@@ -0,0 +1,106 @@
+// Package smotel 基于 OpenTelemetry 实现了 go_sdk.Tracer 接口，单独作为一个 module 发布，
+// 不使用 OpenTelemetry 的项目不会引入相关依赖：
+//      go_sdk.SetTracer(smotel.NewTracer(nil))
+//      router.Use(smotel.Middleware)
+// 处理函数中使用请求的 context 调用 Client，App 操作、开发者服务器的处理以及对平台接口的调用即为同一条链路：
+//      client.CreateMessageContext(r.Context(), cmr)
+package smotel
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	go_sdk "github.com/super-message/go-sdk"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const instrumentationName = "github.com/super-message/go-sdk/smotel"
+
+// Tracer 实现了 go_sdk.Tracer 接口
+type Tracer struct {
+	tracer     trace.Tracer
+	propagator propagation.TextMapPropagator
+}
+
+var _ go_sdk.Tracer = (*Tracer)(nil)
+
+// NewTracer 新建一个 Tracer，tp 为 nil 时使用 otel.GetTracerProvider()，
+// 链路信息使用 otel.GetTextMapPropagator() 传播
+func NewTracer(tp trace.TracerProvider) *Tracer {
+	if tp == nil {
+		tp = otel.GetTracerProvider()
+	}
+
+	return &Tracer{
+		tracer:     tp.Tracer(instrumentationName),
+		propagator: otel.GetTextMapPropagator(),
+	}
+}
+
+func (t *Tracer) Start(ctx context.Context, name string, kind go_sdk.SpanKind, attrs map[string]interface{}) (context.Context, go_sdk.Span) {
+	ctx, span := t.tracer.Start(ctx, name,
+		trace.WithSpanKind(spanKind(kind)),
+		trace.WithAttributes(attributes(attrs)...))
+	return ctx, Span{span}
+}
+
+func (t *Tracer) Inject(ctx context.Context, header http.Header) {
+	t.propagator.Inject(ctx, propagation.HeaderCarrier(header))
+}
+
+// Span 实现了 go_sdk.Span 接口
+type Span struct {
+	trace.Span
+}
+
+func (s Span) SetAttributes(attrs map[string]interface{}) {
+	s.Span.SetAttributes(attributes(attrs)...)
+}
+
+func (s Span) End(err error) {
+	if err != nil {
+		s.Span.RecordError(err)
+		s.Span.SetStatus(codes.Error, err.Error())
+	}
+	s.Span.End()
+}
+
+func spanKind(kind go_sdk.SpanKind) trace.SpanKind {
+	switch kind {
+	case go_sdk.SpanKindClient:
+		return trace.SpanKindClient
+	case go_sdk.SpanKindServer:
+		return trace.SpanKindServer
+	}
+	return trace.SpanKindInternal
+}
+
+func attributes(attrs map[string]interface{}) []attribute.KeyValue {
+	kvs := make([]attribute.KeyValue, 0, len(attrs))
+	for k, v := range attrs {
+		switch v := v.(type) {
+		case string:
+			kvs = append(kvs, attribute.String(k, v))
+		case bool:
+			kvs = append(kvs, attribute.Bool(k, v))
+		case int:
+			kvs = append(kvs, attribute.Int(k, v))
+		case int32:
+			kvs = append(kvs, attribute.Int(k, int(v)))
+		case int64:
+			kvs = append(kvs, attribute.Int64(k, v))
+		case float64:
+			kvs = append(kvs, attribute.Float64(k, v))
+		case []string:
+			kvs = append(kvs, attribute.StringSlice(k, v))
+		default:
+			kvs = append(kvs, attribute.String(k, fmt.Sprint(v)))
+		}
+	}
+	return kvs
+}
@@ -0,0 +1,64 @@
+package smotel
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+	go_sdk "github.com/super-message/go-sdk"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestMiddlewareAndTracer(t *testing.T) {
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
+	Convey("Spans started in a handler join the middleware's trace", t, func() {
+		recorder := tracetest.NewSpanRecorder()
+		tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+		tracer := NewTracer(tp)
+
+		header := http.Header{}
+		handler := NewMiddleware(tp)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx, span := tracer.Start(r.Context(), "sm.CreateMessage", go_sdk.SpanKindInternal, map[string]interface{}{
+				go_sdk.AttrRecipientCount: 2,
+			})
+			_, child := tracer.Start(ctx, "POST /v1/messages", go_sdk.SpanKindClient, nil)
+			tracer.Inject(ctx, header)
+			child.End(errors.New("boom"))
+			span.End(nil)
+			w.WriteHeader(http.StatusAccepted)
+		}))
+
+		r := httptest.NewRequest("POST", "/todo?_rt=rt&_cid=c1&_id=42&_tid=t1&_tv=3", nil)
+		handler.ServeHTTP(httptest.NewRecorder(), r)
+
+		spans := recorder.Ended()
+		So(len(spans), ShouldEqual, 3)
+
+		client, internal, server := spans[0], spans[1], spans[2]
+		So(server.SpanKind(), ShouldEqual, trace.SpanKindServer)
+		So(client.SpanKind(), ShouldEqual, trace.SpanKindClient)
+		So(internal.Parent().SpanID(), ShouldEqual, server.SpanContext().SpanID())
+		So(client.Parent().SpanID(), ShouldEqual, internal.SpanContext().SpanID())
+		So(client.Status().Code, ShouldEqual, codes.Error)
+
+		attrs := map[string]interface{}{}
+		for _, kv := range server.Attributes() {
+			attrs[string(kv.Key)] = kv.Value.AsInterface()
+		}
+		So(attrs[go_sdk.AttrChannelID], ShouldEqual, "c1")
+		So(attrs[go_sdk.AttrMessageID], ShouldEqual, int64(42))
+		So(attrs[go_sdk.AttrTemplateVersion], ShouldEqual, int64(3))
+		So(attrs[go_sdk.AttrHTTPStatus], ShouldEqual, int64(http.StatusAccepted))
+
+		So(strings.Contains(header.Get("traceparent"), server.SpanContext().TraceID().String()), ShouldBeTrue)
+	})
+}
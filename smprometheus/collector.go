@@ -0,0 +1,97 @@
+// Package smprometheus 基于 Prometheus 实现了 go_sdk.Metrics 接口，单独作为一个 module 发布，
+// 不使用 Prometheus 的项目不会引入相关依赖：
+//      collector := smprometheus.NewCollector("myapp")
+//      prometheus.MustRegister(collector)
+//      go_sdk.SetMetrics(collector)
+package smprometheus
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	go_sdk "github.com/super-message/go-sdk"
+)
+
+// Collector 同时实现了 prometheus.Collector 和 go_sdk.Metrics 接口，导出以下指标：
+//      <namespace>_sm_client_requests_total{endpoint,method,status,code}    平台接口调用次数
+//      <namespace>_sm_client_request_duration_seconds{endpoint,method}      平台接口调用耗时
+//      <namespace>_sm_client_token_cache_total{result}                      request token 缓存命中（hit）和未命中（miss）次数
+//      <namespace>_sm_server_responses_total{kind}                          Response.Output 输出的各类操作次数
+type Collector struct {
+	requests  *prometheus.CounterVec
+	latency   *prometheus.HistogramVec
+	cache     *prometheus.CounterVec
+	responses *prometheus.CounterVec
+}
+
+var _ go_sdk.Metrics = (*Collector)(nil)
+
+// NewCollector 新建一个 Collector，namespace 为指标名称的前缀，可以为空
+func NewCollector(namespace string) *Collector {
+	return &Collector{
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "sm_client",
+			Name:      "requests_total",
+			Help:      "Number of requests to the super message platform api.",
+		}, []string{"endpoint", "method", "status", "code"}),
+		latency: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "sm_client",
+			Name:      "request_duration_seconds",
+			Help:      "Latency of requests to the super message platform api.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"endpoint", "method"}),
+		cache: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "sm_client",
+			Name:      "token_cache_total",
+			Help:      "Number of request token cache lookups by result.",
+		}, []string{"result"}),
+		responses: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "sm_server",
+			Name:      "responses_total",
+			Help:      "Number of actions written by Response.Output by kind.",
+		}, []string{"kind"}),
+	}
+}
+
+func (c *Collector) Describe(ch chan<- *prometheus.Desc) {
+	c.requests.Describe(ch)
+	c.latency.Describe(ch)
+	c.cache.Describe(ch)
+	c.responses.Describe(ch)
+}
+
+func (c *Collector) Collect(ch chan<- prometheus.Metric) {
+	c.requests.Collect(ch)
+	c.latency.Collect(ch)
+	c.cache.Collect(ch)
+	c.responses.Collect(ch)
+}
+
+func (c *Collector) ObserveRequest(endpoint go_sdk.Endpoint, method string, status, code int, latency time.Duration) {
+	c.requests.WithLabelValues(string(endpoint), method, strconv.Itoa(status), strconv.Itoa(code)).Inc()
+	c.latency.WithLabelValues(string(endpoint), method).Observe(latency.Seconds())
+}
+
+func (c *Collector) ObserveCache(hit bool) {
+	result := "miss"
+	if hit {
+		result = "hit"
+	}
+	c.cache.WithLabelValues(result).Inc()
+}
+
+func (c *Collector) ObserveResponse(kinds []string) {
+	if len(kinds) == 0 {
+		c.responses.WithLabelValues("empty").Inc()
+		return
+	}
+
+	for _, kind := range kinds {
+		c.responses.WithLabelValues(kind).Inc()
+	}
+}
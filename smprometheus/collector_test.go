@@ -0,0 +1,32 @@
+package smprometheus
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	. "github.com/smartystreets/goconvey/convey"
+	go_sdk "github.com/super-message/go-sdk"
+)
+
+func TestCollector(t *testing.T) {
+	Convey("Requests, cache lookups and responses are collected", t, func() {
+		c := NewCollector("test")
+		registry := prometheus.NewRegistry()
+		So(registry.Register(c), ShouldBeNil)
+
+		c.ObserveRequest(go_sdk.EndpointMessages, "POST", 200, 0, 20*time.Millisecond)
+		c.ObserveRequest(go_sdk.EndpointVerify, "GET", 200, 10001, 5*time.Millisecond)
+		c.ObserveCache(true)
+		c.ObserveCache(false)
+		c.ObserveCache(true)
+		c.ObserveResponse([]string{"update", "dismiss"})
+		c.ObserveResponse(nil)
+
+		So(testutil.ToFloat64(c.requests.WithLabelValues("verify", "GET", "200", "10001")), ShouldEqual, 1)
+		So(testutil.ToFloat64(c.cache.WithLabelValues("hit")), ShouldEqual, 2)
+		So(testutil.ToFloat64(c.responses.WithLabelValues("empty")), ShouldEqual, 1)
+		So(testutil.CollectAndCount(c), ShouldEqual, 9)
+	})
+}
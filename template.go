@@ -0,0 +1,86 @@
+package go_sdk
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"strings"
+)
+
+// Template 描述一个消息模板文件，模板文件为 JSON 格式：
+//      {
+//          "name": "todo-list",
+//          "id": "5e9f0c7a8d1b2c3d4e5f6a7b",
+//          "version": 3,
+//          "data": {
+//              "title": "string",
+//              "list": [{"id": "int", "title": "string", "done": "bool"}]
+//          },
+//          "source": "<模板源码>"
+//      }
+// 其中 data 描述模板中数据绑定的结构，值为类型名称（string、bool、int、int64、float64、any），
+// 对象表示嵌套结构，只有一个元素的数组表示该元素类型的列表
+type Template struct {
+	// 模板的逻辑名称，在代码中通过名称引用模板，避免直接使用 ID 和版本号
+	Name string `json:"name"`
+
+	// 模板 ID 和版本号，从后台模板管理中获取
+	ID      string `json:"id"`
+	Version int    `json:"version"`
+
+	// 模板数据绑定的结构描述
+	Data map[string]interface{} `json:"data,omitempty"`
+
+	// 模板源码
+	Source string `json:"source,omitempty"`
+}
+
+var (
+	ErrTemplateNameRequired = errors.New("template name is required")
+)
+
+// ParseTemplate 从 JSON 数据中解析出模板
+func ParseTemplate(b []byte) (t *Template, err error) {
+	t = &Template{}
+	if err = json.Unmarshal(b, t); err != nil {
+		return nil, err
+	}
+
+	t.Name = strings.TrimSpace(t.Name)
+	if t.Name == "" {
+		return nil, ErrTemplateNameRequired
+	}
+
+	t.ID = strings.TrimSpace(t.ID)
+	return
+}
+
+// LoadTemplateFile 读取并解析一个模板文件
+func LoadTemplateFile(filename string) (*Template, error) {
+	b, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	return ParseTemplate(b)
+}
+
+// SaveTemplateFile 将模板写入模板文件，比如 UploadTemplate 更新了模板的 ID 和版本号之后写回原文件
+func SaveTemplateFile(filename string, t *Template) error {
+	buf := new(bytes.Buffer)
+	encoder := json.NewEncoder(buf)
+	// 模板源码中有大量 <、> 字符，保持原样便于阅读和比较
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(t); err != nil {
+		return err
+	}
+
+	mode := os.FileMode(0644)
+	if info, err := os.Stat(filename); err == nil {
+		mode = info.Mode()
+	}
+	return ioutil.WriteFile(filename, buf.Bytes(), mode)
+}
@@ -0,0 +1,104 @@
+package go_sdk
+
+import (
+	"fmt"
+	"sync"
+)
+
+// MigrationFunc 将模板某个版本的消息数据转换为下一个版本的数据
+type MigrationFunc func(data interface{}) (interface{}, error)
+
+// Migrator 管理模板各版本之间消息数据的转换。模板升级版本后，已推送的消息仍然使用旧版本的模板和数据，
+// 当用户在旧消息上操作时，可以通过 Migrator 将数据逐个版本转换到当前版本，并更新消息为当前版本的模板
+type Migrator struct {
+	registry *TemplateRegistry
+
+	mutex sync.RWMutex
+	// 模板名称 → 起始版本号 → 转换函数
+	steps map[string]map[int]MigrationFunc
+}
+
+func NewMigrator(registry *TemplateRegistry) *Migrator {
+	return &Migrator{
+		registry: registry,
+		steps:    make(map[string]map[int]MigrationFunc),
+	}
+}
+
+// Register 注册名称为 name 的模板从 fromVersion 升级到下一个版本（registry 中比 fromVersion 大的最小版本）
+// 时的数据转换函数。没有注册转换函数的版本步骤视为数据结构没有变化，数据原样保留
+func (m *Migrator) Register(name string, fromVersion int, fn MigrationFunc) *Migrator {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	steps, ok := m.steps[name]
+	if !ok {
+		steps = make(map[int]MigrationFunc)
+		m.steps[name] = steps
+	}
+
+	steps[fromVersion] = fn
+	return m
+}
+
+// Migrate 将模板 ID 为 templateID、版本号为 version 的消息数据转换为当前版本的数据，返回当前版本的模板
+func (m *Migrator) Migrate(templateID string, version int, data interface{}) (t *Template, migrated interface{}, err error) {
+	t, err = m.registry.CurrentByID(templateID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	versions := m.registry.Versions(t.Name)
+
+	// 复制转换函数，避免与 Register 并发读写同一个 map
+	m.mutex.RLock()
+	steps := make(map[int]MigrationFunc, len(m.steps[t.Name]))
+	for v, fn := range m.steps[t.Name] {
+		steps[v] = fn
+	}
+	m.mutex.RUnlock()
+
+	migrated = data
+	for cur := version; cur < t.Version; {
+		next := t.Version
+		for _, v := range versions {
+			if v.Version > cur {
+				next = v.Version
+				break
+			}
+		}
+
+		if fn := steps[cur]; fn != nil {
+			migrated, err = fn(migrated)
+			if err != nil {
+				return nil, nil, fmt.Errorf("failed to migrate template %q from version %d to %d: %s", t.Name, cur, next, err)
+			}
+		}
+
+		cur = next
+	}
+
+	return
+}
+
+// UpgradeThisMessage 在请求所在消息的模板版本比当前版本旧时，将消息数据 data 转换为当前版本的数据，
+// 并通过 UpdateThisMessageWithTemplate 将消息更新为当前版本的模板，返回是否进行了升级。
+// data 为旧消息的数据，通常从开发者自己的存储中获取：
+//      upgraded, err := migrator.UpgradeThisMessage(resp, q, "待办列表", oldData)
+//      if err == nil && upgraded {
+//          _ = resp.Output(w)
+//          return
+//      }
+func (m *Migrator) UpgradeThisMessage(resp *Response, q *QueryParameter, title string, data interface{}) (upgraded bool, err error) {
+	if !m.registry.IsOutdated(q) {
+		return false, nil
+	}
+
+	t, migrated, err := m.Migrate(q.TemplateID, q.TemplateVersion, data)
+	if err != nil {
+		return false, err
+	}
+
+	resp.UpdateThisMessageWithTemplate(q, t.ID, t.Version, title, migrated)
+	return true, nil
+}
@@ -0,0 +1,87 @@
+package go_sdk
+
+import (
+	"errors"
+	"sync"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestMigrator(t *testing.T) {
+	Convey("Migrate message data across template versions", t, func() {
+		registry := NewTemplateRegistry()
+		for _, v := range []int{2, 3, 5} {
+			So(registry.Register(&Template{Name: "todo-list", ID: "t1", Version: v}), ShouldBeNil)
+		}
+
+		var trace []int
+		migrator := NewMigrator(registry).
+			Register("todo-list", 1, func(data interface{}) (interface{}, error) {
+				trace = append(trace, 1)
+				m := data.(map[string]interface{})
+				return map[string]interface{}{"list": m["todos"]}, nil
+			}).
+			Register("todo-list", 3, func(data interface{}) (interface{}, error) {
+				trace = append(trace, 3)
+				m := data.(map[string]interface{})
+				m["count"] = len(m["list"].([]string))
+				return m, nil
+			})
+
+		Convey("Every registered step is applied in order", func() {
+			tpl, data, err := migrator.Migrate("t1", 1, map[string]interface{}{"todos": []string{"a", "b"}})
+			So(err, ShouldBeNil)
+			So(tpl.Version, ShouldEqual, 5)
+			So(trace, ShouldResemble, []int{1, 3})
+			So(data, ShouldResemble, map[string]interface{}{"list": []string{"a", "b"}, "count": 2})
+		})
+
+		Convey("Outdated messages are upgraded in the response", func() {
+			resp := NewResponse()
+			q := &QueryParameter{MessageID: 10, TemplateID: "t1", TemplateVersion: 3}
+			upgraded, err := migrator.UpgradeThisMessage(resp, q, "待办列表", map[string]interface{}{"list": []string{"a"}})
+			So(err, ShouldBeNil)
+			So(upgraded, ShouldBeTrue)
+			So(resp.Update.ID, ShouldEqual, 10)
+			So(resp.Update.TemplateVersion, ShouldEqual, 5)
+			So(trace, ShouldResemble, []int{3})
+		})
+
+		Convey("Current messages are left untouched", func() {
+			resp := NewResponse()
+			upgraded, err := migrator.UpgradeThisMessage(resp, &QueryParameter{TemplateID: "t1", TemplateVersion: 5}, "待办列表", nil)
+			So(err, ShouldBeNil)
+			So(upgraded, ShouldBeFalse)
+			So(resp.Update, ShouldBeNil)
+		})
+
+		Convey("Migration errors are reported", func() {
+			migrator.Register("todo-list", 2, func(data interface{}) (interface{}, error) {
+				return nil, errors.New("broken")
+			})
+			_, _, err := migrator.Migrate("t1", 2, nil)
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("Steps can be registered while migrating", func() {
+			identity := func(data interface{}) (interface{}, error) { return data, nil }
+			migrator := NewMigrator(registry).Register("todo-list", 2, identity)
+
+			var wg sync.WaitGroup
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for v := 10; v < 200; v++ {
+					migrator.Register("todo-list", v, identity)
+				}
+			}()
+
+			for i := 0; i < 200; i++ {
+				_, _, err := migrator.Migrate("t1", 2, nil)
+				So(err, ShouldBeNil)
+			}
+			wg.Wait()
+		})
+	})
+}
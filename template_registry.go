@@ -0,0 +1,226 @@
+package go_sdk
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// TemplateRegistry 集中管理模板的逻辑名称与模板 ID、版本号的对应关系，代码中只需要通过名称引用模板，
+// 升级模板版本时只需要更新模板文件，而不必修改散落在各处的 ID 和版本号。
+// 同一个模板可以注册多个版本，版本号最大的为当前版本，旧版本用于识别和迁移旧消息
+type TemplateRegistry struct {
+	mutex sync.RWMutex
+	// 逻辑名称 → 按版本号升序排列的模板
+	templates map[string][]*Template
+	// 模板 ID → 逻辑名称
+	names map[string]string
+}
+
+func NewTemplateRegistry() *TemplateRegistry {
+	return &TemplateRegistry{
+		templates: make(map[string][]*Template),
+		names:     make(map[string]string),
+	}
+}
+
+// Register 注册一个模板，同一名称的模板必须使用相同的模板 ID，重复注册同一版本则覆盖旧的
+func (r *TemplateRegistry) Register(t *Template) error {
+	if t.Name == "" {
+		return ErrTemplateNameRequired
+	}
+
+	if t.ID == "" {
+		return ErrTemplateIDRequired
+	}
+
+	if t.Version < 1 {
+		return ErrInvalidTemplateVersion
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if name, ok := r.names[t.ID]; ok && name != t.Name {
+		return fmt.Errorf("template id %s is already registered as %q", t.ID, name)
+	}
+
+	versions := r.templates[t.Name]
+	if len(versions) > 0 && versions[0].ID != t.ID {
+		return fmt.Errorf("template %q is already registered with id %s", t.Name, versions[0].ID)
+	}
+
+	i := sort.Search(len(versions), func(i int) bool { return versions[i].Version >= t.Version })
+	if i < len(versions) && versions[i].Version == t.Version {
+		versions[i] = t
+	} else {
+		versions = append(versions, nil)
+		copy(versions[i+1:], versions[i:])
+		versions[i] = t
+	}
+
+	r.templates[t.Name] = versions
+	r.names[t.ID] = t.Name
+	return nil
+}
+
+// LoadDir 加载目录下所有 .json 模板文件
+func (r *TemplateRegistry) LoadDir(dir string) error {
+	infos, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+
+	for _, info := range infos {
+		if info.IsDir() || !strings.HasSuffix(info.Name(), ".json") {
+			continue
+		}
+
+		filename := filepath.Join(dir, info.Name())
+		t, err := LoadTemplateFile(filename)
+		if err != nil {
+			return fmt.Errorf("%s: %s", filename, err)
+		}
+
+		if err = r.Register(t); err != nil {
+			return fmt.Errorf("%s: %s", filename, err)
+		}
+	}
+
+	return nil
+}
+
+// LoadFileSystem 加载 fs 中 dir 目录下所有 .json 模板文件，可用于加载打包进二进制文件的模板，
+// 比如使用 Go 1.16 的 embed：
+//      //go:embed templates
+//      var templates embed.FS
+//
+//      registry.LoadFileSystem(http.FS(templates), "templates")
+func (r *TemplateRegistry) LoadFileSystem(fs http.FileSystem, dir string) error {
+	d, err := fs.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+
+	infos, err := d.Readdir(-1)
+	if err != nil {
+		return err
+	}
+
+	for _, info := range infos {
+		if info.IsDir() || !strings.HasSuffix(info.Name(), ".json") {
+			continue
+		}
+
+		filename := path.Join(dir, info.Name())
+		if err = r.loadFile(fs, filename); err != nil {
+			return fmt.Errorf("%s: %s", filename, err)
+		}
+	}
+
+	return nil
+}
+
+func (r *TemplateRegistry) loadFile(fs http.FileSystem, filename string) error {
+	f, err := fs.Open(filename)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	b, err := ioutil.ReadAll(f)
+	if err != nil {
+		return err
+	}
+
+	t, err := ParseTemplate(b)
+	if err != nil {
+		return err
+	}
+
+	return r.Register(t)
+}
+
+// Current 返回名称为 name 的模板的当前（最新）版本
+func (r *TemplateRegistry) Current(name string) (*Template, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	versions := r.templates[name]
+	if len(versions) == 0 {
+		return nil, fmt.Errorf("template %q not found", name)
+	}
+
+	return versions[len(versions)-1], nil
+}
+
+// Version 返回名称为 name 的模板的指定版本
+func (r *TemplateRegistry) Version(name string, version int) (*Template, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	for _, t := range r.templates[name] {
+		if t.Version == version {
+			return t, nil
+		}
+	}
+
+	return nil, fmt.Errorf("template %q version %d not found", name, version)
+}
+
+// Versions 返回名称为 name 的模板已注册的所有版本，按版本号升序排列
+func (r *TemplateRegistry) Versions(name string) []*Template {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	return append([]*Template(nil), r.templates[name]...)
+}
+
+// CurrentByID 根据模板 ID 返回该模板的当前版本
+func (r *TemplateRegistry) CurrentByID(id string) (*Template, error) {
+	r.mutex.RLock()
+	name, ok := r.names[id]
+	r.mutex.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("template id %s not found", id)
+	}
+
+	return r.Current(name)
+}
+
+// IsOutdated 返回请求所在消息使用的模板版本（_tv）是否比当前版本旧，
+// 对于旧版本的消息，处理函数可以将消息更新为当前版本的模板，未注册的模板始终返回 false
+func (r *TemplateRegistry) IsOutdated(q *QueryParameter) bool {
+	if q.TemplateID == "" || q.TemplateVersion <= 0 {
+		return false
+	}
+
+	t, err := r.CurrentByID(q.TemplateID)
+	if err != nil {
+		return false
+	}
+
+	return q.TemplateVersion < t.Version
+}
+
+// MessageContent 使用名称为 name 的模板的当前版本构建消息内容
+func (r *TemplateRegistry) MessageContent(name, title string, data map[string]interface{}) (mcr MessageContentRequest, err error) {
+	t, err := r.Current(name)
+	if err != nil {
+		return
+	}
+
+	mcr = MessageContentRequest{
+		TemplateID:      t.ID,
+		TemplateVersion: int32(t.Version),
+		Title:           title,
+		Data:            data,
+	}
+	return
+}
@@ -0,0 +1,51 @@
+package go_sdk
+
+import (
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestTemplateRegistry(t *testing.T) {
+	Convey("Register several versions of a template", t, func() {
+		registry := NewTemplateRegistry()
+		So(registry.Register(&Template{Name: "todo-list", ID: "t1", Version: 2}), ShouldBeNil)
+		So(registry.Register(&Template{Name: "todo-list", ID: "t1", Version: 3}), ShouldBeNil)
+		So(registry.Register(&Template{Name: "todo-list", ID: "t1", Version: 1}), ShouldBeNil)
+
+		Convey("The latest version is current", func() {
+			current, err := registry.Current("todo-list")
+			So(err, ShouldBeNil)
+			So(current.Version, ShouldEqual, 3)
+
+			current, err = registry.CurrentByID("t1")
+			So(err, ShouldBeNil)
+			So(current.Version, ShouldEqual, 3)
+
+			versions := registry.Versions("todo-list")
+			So(len(versions), ShouldEqual, 3)
+			So(versions[0].Version, ShouldEqual, 1)
+		})
+
+		Convey("Conflicting ids are rejected", func() {
+			So(registry.Register(&Template{Name: "todo-list", ID: "t2", Version: 4}), ShouldNotBeNil)
+			So(registry.Register(&Template{Name: "new-todo", ID: "t1", Version: 1}), ShouldNotBeNil)
+		})
+
+		Convey("Requests from old messages are outdated", func() {
+			So(registry.IsOutdated(&QueryParameter{TemplateID: "t1", TemplateVersion: 2}), ShouldBeTrue)
+			So(registry.IsOutdated(&QueryParameter{TemplateID: "t1", TemplateVersion: 3}), ShouldBeFalse)
+			So(registry.IsOutdated(&QueryParameter{TemplateID: "unknown", TemplateVersion: 1}), ShouldBeFalse)
+		})
+
+		Convey("Building message content uses the current version", func() {
+			mcr, err := registry.MessageContent("todo-list", "待办列表", nil)
+			So(err, ShouldBeNil)
+			So(mcr.TemplateID, ShouldEqual, "t1")
+			So(mcr.TemplateVersion, ShouldEqual, 3)
+
+			_, err = registry.MessageContent("unknown", "待办列表", nil)
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
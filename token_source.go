@@ -0,0 +1,218 @@
+package go_sdk
+
+import (
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// TokenSource 提供调用平台接口使用的 access token，用于在不重启服务的情况下轮换 access token。
+// 在开发者后台重新生成 access token 后，旧 token 在一段时间内仍然有效，TokenSource 可以同时提供新旧两个 token，
+// 使用其中一个鉴权失败时，Client 会通过 Fallback 获取另一个 token 重试一次，重试成功后 Token 仍然返回失败的 token 时，
+// Client 直接使用重试成功的 token
+type TokenSource interface {
+	// Token 返回当前使用的 access token
+	Token() (string, error)
+	// Fallback 在使用 failed 鉴权失败后调用，返回用于重试的 token，没有其它可用的 token 时返回空字符串
+	Fallback(failed string) (string, error)
+}
+
+// nextToken 返回 tokens 中第一个不为 failed 的 token
+func nextToken(tokens []string, failed string) string {
+	for _, token := range tokens {
+		if token != "" && token != failed {
+			return token
+		}
+	}
+	return ""
+}
+
+// StaticTokenSource 返回固定的 access token，fallbacks 为轮换期间的备用 token
+func StaticTokenSource(token string, fallbacks ...string) TokenSource {
+	return staticTokenSource(append([]string{token}, fallbacks...))
+}
+
+type staticTokenSource []string
+
+func (s staticTokenSource) Token() (string, error) {
+	return s[0], nil
+}
+
+func (s staticTokenSource) Fallback(failed string) (string, error) {
+	return nextToken(s, failed), nil
+}
+
+// EnvTokenSource 每次从环境变量中读取 access token，names 中第一个为当前使用的 token，其余为备用 token：
+//      EnvTokenSource("SM_ACCESS_TOKEN", "SM_ACCESS_TOKEN_NEXT")
+func EnvTokenSource(names ...string) TokenSource {
+	return envTokenSource(names)
+}
+
+type envTokenSource []string
+
+func (s envTokenSource) tokens() []string {
+	tokens := make([]string, len(s))
+	for i, name := range s {
+		tokens[i] = strings.TrimSpace(os.Getenv(name))
+	}
+	return tokens
+}
+
+func (s envTokenSource) Token() (string, error) {
+	return nextToken(s.tokens(), ""), nil
+}
+
+func (s envTokenSource) Fallback(failed string) (string, error) {
+	return nextToken(s.tokens(), failed), nil
+}
+
+// TokenSourceFunc 将一个函数适配为 TokenSource，比如从配置中心或密钥管理服务获取 access token，
+// 鉴权失败时再调用一次该函数，返回的 token 与失败的不同时使用新 token 重试
+type TokenSourceFunc func() (string, error)
+
+func (f TokenSourceFunc) Token() (string, error) {
+	return f()
+}
+
+func (f TokenSourceFunc) Fallback(failed string) (string, error) {
+	token, err := f()
+	if err != nil || token == failed {
+		return "", err
+	}
+	return token, nil
+}
+
+// FileTokenSource 从文件中读取 access token，每隔 interval 检查一次文件是否有修改，鉴权失败时立即重新读取。
+// 文件中每行一个 token，第一行为当前使用的 token，其余为轮换期间的备用 token，空行和 # 开头的行会被忽略。
+// 适用于 Kubernetes Secret 等以文件形式挂载的密钥
+type FileTokenSource struct {
+	filename string
+	interval time.Duration
+
+	mutex     sync.Mutex
+	tokens    []string
+	modTime   time.Time
+	checkedAt time.Time
+}
+
+// NewFileTokenSource 新建一个 FileTokenSource，并立即读取一次文件，interval 小于等于 0 时为 10 秒
+func NewFileTokenSource(filename string, interval time.Duration) (*FileTokenSource, error) {
+	if interval <= 0 {
+		interval = 10 * time.Second
+	}
+
+	s := &FileTokenSource{filename: filename, interval: interval}
+	if err := s.reload(true); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// reload 在文件修改时间变化后重新读取文件，force 为 true 时忽略检查间隔，调用前需要持有锁（NewFileTokenSource 除外）
+func (s *FileTokenSource) reload(force bool) error {
+	now := time.Now()
+	if !force && now.Sub(s.checkedAt) < s.interval {
+		return nil
+	}
+	s.checkedAt = now
+
+	info, err := os.Stat(s.filename)
+	if err != nil {
+		return err
+	}
+	if !force && info.ModTime().Equal(s.modTime) {
+		return nil
+	}
+
+	b, err := ioutil.ReadFile(s.filename)
+	if err != nil {
+		return err
+	}
+
+	var tokens []string
+	for _, line := range strings.Split(string(b), "\n") {
+		line = strings.TrimSpace(line)
+		if line != "" && !strings.HasPrefix(line, "#") {
+			tokens = append(tokens, line)
+		}
+	}
+
+	s.tokens = tokens
+	s.modTime = info.ModTime()
+	return nil
+}
+
+func (s *FileTokenSource) Token() (string, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	// 读取失败时继续使用之前读取的 token，避免文件替换过程中短暂不存在导致请求失败
+	if err := s.reload(false); err != nil && len(s.tokens) == 0 {
+		return "", err
+	}
+	return nextToken(s.tokens, ""), nil
+}
+
+func (s *FileTokenSource) Fallback(failed string) (string, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if err := s.reload(true); err != nil && len(s.tokens) == 0 {
+		return "", err
+	}
+	return nextToken(s.tokens, failed), nil
+}
+
+// WithTokenSource 设置 Client 获取 access token 的方式，设置后忽略 NewClient 传入的 accessToken
+func WithTokenSource(ts TokenSource) ClientOption {
+	return func(c *Client) {
+		c.tokenSource = ts
+	}
+}
+
+// WithAuthErrorCodes 设置平台表示 access token 无效的 APIError 错误码，收到这些错误码以及 HTTP 401、403 状态码时，
+// Client 会通过 TokenSource.Fallback 获取另一个 token 重试一次
+func WithAuthErrorCodes(codes ...int) ClientOption {
+	return func(c *Client) {
+		for _, code := range codes {
+			c.authCodes[code] = true
+		}
+	}
+}
+
+// preferredToken 返回本次请求使用的 token：current 曾经鉴权失败并且备用 token 重试成功时返回备用 token，
+// 避免轮换后 StaticTokenSource 等仍然返回旧 token 时每个请求都发送两次
+func (c *Client) preferredToken(current string) string {
+	c.tokenMutex.Lock()
+	defer c.tokenMutex.Unlock()
+
+	if current == c.rejectedToken && c.fallbackToken != "" {
+		return c.fallbackToken
+	}
+	return current
+}
+
+// rememberToken 记录 current 鉴权失败后 fallback 重试成功，fallback 与 current 相同时清除记录
+func (c *Client) rememberToken(current, fallback string) {
+	c.tokenMutex.Lock()
+	defer c.tokenMutex.Unlock()
+
+	if current == fallback {
+		c.rejectedToken, c.fallbackToken = "", ""
+		return
+	}
+	c.rejectedToken, c.fallbackToken = current, fallback
+}
+
+func (c *Client) isAuthError(err error) bool {
+	switch e := err.(type) {
+	case *APIError:
+		return c.authCodes[e.Code]
+	case *StatusError:
+		return e.StatusCode == http.StatusUnauthorized || e.StatusCode == http.StatusForbidden
+	}
+	return false
+}
@@ -0,0 +1,112 @@
+package go_sdk
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestTokenSource(t *testing.T) {
+	var bodies []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := ioutil.ReadAll(r.Body)
+		bodies = append(bodies, string(b))
+
+		if r.URL.Query().Get("accessToken") != "new" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"code": 0, "data": map[string]int64{"id": 1}})
+	}))
+	defer server.Close()
+
+	cmr := &CreateMessageRequest{
+		ToAll:                 true,
+		MessageContentRequest: MessageContentRequest{TemplateID: "t1", TemplateVersion: 1, Title: "title"},
+	}
+
+	Convey("Requests rejected for the access token are retried once with the fallback token", t, func() {
+		bodies = nil
+		client := NewClient("", nil, WithAPIHost(server.URL), WithTokenSource(StaticTokenSource("old", "new")))
+
+		id, err := client.CreateMessage(cmr)
+		So(err, ShouldBeNil)
+		So(id, ShouldEqual, 1)
+		So(len(bodies), ShouldEqual, 2)
+		So(bodies[1], ShouldEqual, bodies[0])
+
+		// 之后的请求直接使用重试成功的 token
+		_, err = client.CreateMessage(cmr)
+		So(err, ShouldBeNil)
+		So(len(bodies), ShouldEqual, 3)
+
+		client = NewClient("old", nil, WithAPIHost(server.URL))
+		_, err = client.CreateMessage(cmr)
+		So(err, ShouldResemble, &StatusError{StatusCode: http.StatusUnauthorized})
+	})
+
+	Convey("The retry with the fallback token waits for the rate limiter", t, func() {
+		bodies = nil
+		client := NewClient("", nil, WithAPIHost(server.URL), WithTokenSource(StaticTokenSource("old", "new")),
+			WithRateLimit(EndpointMessages, 0.001, 1), WithRateLimitMode(RateLimitFailFast))
+
+		_, err := client.CreateMessage(cmr)
+		So(err, ShouldEqual, ErrRateLimited)
+		So(len(bodies), ShouldEqual, 1)
+	})
+
+	Convey("The remembered token is dropped when it is rejected", t, func() {
+		var valid atomic.Value
+		valid.Store("new")
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Query().Get("accessToken") != valid.Load().(string) {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{"code": 0, "data": map[string]int64{"id": 1}})
+		}))
+		defer server.Close()
+
+		client := NewClient("", nil, WithAPIHost(server.URL), WithTokenSource(StaticTokenSource("old", "new")))
+		_, err := client.CreateMessage(cmr)
+		So(err, ShouldBeNil)
+		So(client.preferredToken("old"), ShouldEqual, "new")
+
+		valid.Store("old")
+		_, err = client.CreateMessage(cmr)
+		So(err, ShouldBeNil)
+		So(client.preferredToken("old"), ShouldEqual, "old")
+	})
+
+	Convey("FileTokenSource reloads the file when the token is rejected", t, func() {
+		dir, _ := ioutil.TempDir("", "sm-token")
+		defer os.RemoveAll(dir)
+
+		filename := filepath.Join(dir, "token")
+		_ = ioutil.WriteFile(filename, []byte("# access token\nold\n"), 0600)
+
+		ts, err := NewFileTokenSource(filename, time.Hour)
+		So(err, ShouldBeNil)
+		token, _ := ts.Token()
+		So(token, ShouldEqual, "old")
+
+		_ = ioutil.WriteFile(filename, []byte("new\nold\n"), 0600)
+		token, _ = ts.Token()
+		So(token, ShouldEqual, "old")
+
+		client := NewClient("", nil, WithAPIHost(server.URL), WithTokenSource(ts))
+		_, err = client.CreateMessage(cmr)
+		So(err, ShouldBeNil)
+
+		token, _ = ts.Token()
+		So(token, ShouldEqual, "new")
+	})
+}
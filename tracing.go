@@ -0,0 +1,111 @@
+package go_sdk
+
+import (
+	"context"
+	"net/http"
+	"sync"
+)
+
+// SpanKind 表示 span 的类型
+type SpanKind int
+
+const (
+	// SpanKindInternal 表示 SDK 内部的操作，比如 CreateMessage
+	SpanKindInternal SpanKind = iota
+	// SpanKindClient 表示对平台接口发起的 HTTP 请求
+	SpanKindClient
+	// SpanKindServer 表示处理 App 客户端发起的请求
+	SpanKindServer
+)
+
+// Tracer 定义了 SDK 链路追踪的接口，不依赖任何追踪系统，
+// github.com/super-message/go-sdk/smotel 包提供了基于 OpenTelemetry 的实现。
+// Client 的每个方法都会生成一个 span，对平台接口发起的每个 HTTP 请求再生成一个子 span，
+// 使用 ...Context 版本的方法并传入请求的 context，即可将 App 操作 → 开发者服务器 → 平台接口串联为一条链路
+type Tracer interface {
+	// Start 开始一个 span，返回包含该 span 的 context
+	Start(ctx context.Context, name string, kind SpanKind, attrs map[string]interface{}) (context.Context, Span)
+	// Inject 将 ctx 中的链路信息写入对平台接口发起的 HTTP 请求的 header
+	Inject(ctx context.Context, header http.Header)
+}
+
+// Span 表示链路中的一个操作
+type Span interface {
+	SetAttributes(attrs map[string]interface{})
+	// End 结束 span，err 不为 nil 时将 span 标记为失败
+	End(err error)
+}
+
+type nopTracer struct{}
+
+func (nopTracer) Start(ctx context.Context, name string, kind SpanKind, attrs map[string]interface{}) (context.Context, Span) {
+	return ctx, nopSpan{}
+}
+func (nopTracer) Inject(ctx context.Context, header http.Header) {}
+
+type nopSpan struct{}
+
+func (nopSpan) SetAttributes(attrs map[string]interface{}) {}
+func (nopSpan) End(err error)                              {}
+
+var (
+	tracerMutex   sync.RWMutex
+	defaultTracer Tracer = nopTracer{}
+)
+
+// SetTracer 设置 SDK 默认的 Tracer，用于没有通过 WithTracer 单独设置 Tracer 的 Client
+func SetTracer(t Tracer) {
+	if t == nil {
+		t = nopTracer{}
+	}
+
+	tracerMutex.Lock()
+	defaultTracer = t
+	tracerMutex.Unlock()
+}
+
+func getTracer() Tracer {
+	tracerMutex.RLock()
+	defer tracerMutex.RUnlock()
+
+	return defaultTracer
+}
+
+// WithTracer 设置 Client 使用的 Tracer
+func WithTracer(t Tracer) ClientOption {
+	return func(c *Client) {
+		c.tracer = t
+	}
+}
+
+func (c *Client) trace() Tracer {
+	if c.tracer != nil {
+		return c.tracer
+	}
+	return getTracer()
+}
+
+// 链路追踪中使用的属性名称
+const (
+	AttrTemplateID      = "sm.template.id"
+	AttrTemplateVersion = "sm.template.version"
+	AttrMessageID       = "sm.message.id"
+	AttrRecipientCount  = "sm.recipient.count"
+	AttrToAll           = "sm.to_all"
+	AttrMessageCount    = "sm.message.count"
+	AttrFailedCount     = "sm.message.failed"
+	AttrCacheHit        = "sm.cache.hit"
+	AttrChannelID       = "sm.channel.id"
+	AttrEndpoint        = "sm.endpoint"
+	AttrHTTPMethod      = "http.method"
+	AttrHTTPPath        = "http.path"
+	AttrHTTPStatus      = "http.status_code"
+	AttrErrorCode       = "sm.error.code"
+)
+
+func contentAttributes(mcr *MessageContentRequest) map[string]interface{} {
+	return map[string]interface{}{
+		AttrTemplateID:      mcr.TemplateID,
+		AttrTemplateVersion: int(mcr.TemplateVersion),
+	}
+}
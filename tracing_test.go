@@ -0,0 +1,91 @@
+package go_sdk
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+type spanKey struct{}
+
+type recordedSpan struct {
+	name   string
+	parent string
+	attrs  map[string]interface{}
+	err    error
+}
+
+type recordingTracer struct {
+	mutex sync.Mutex
+	spans []*recordedSpan
+}
+
+func (t *recordingTracer) Start(ctx context.Context, name string, kind SpanKind, attrs map[string]interface{}) (context.Context, Span) {
+	s := &recordedSpan{name: name, attrs: map[string]interface{}{}}
+	if parent, ok := ctx.Value(spanKey{}).(*recordedSpan); ok {
+		s.parent = parent.name
+	}
+	s.SetAttributes(attrs)
+
+	t.mutex.Lock()
+	t.spans = append(t.spans, s)
+	t.mutex.Unlock()
+	return context.WithValue(ctx, spanKey{}, s), s
+}
+
+func (t *recordingTracer) Inject(ctx context.Context, header http.Header) {
+	header.Set("X-Span", ctx.Value(spanKey{}).(*recordedSpan).name)
+}
+
+func (s *recordedSpan) SetAttributes(attrs map[string]interface{}) {
+	for k, v := range attrs {
+		s.attrs[k] = v
+	}
+}
+
+func (s *recordedSpan) End(err error) { s.err = err }
+
+func TestClientTracing(t *testing.T) {
+	var header string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header = r.Header.Get("X-Span")
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"code": 0, "data": map[string]int64{"id": 7}})
+	}))
+	defer server.Close()
+
+	originHost := apiHost
+	apiHost = server.URL
+	defer func() { apiHost = originHost }()
+
+	Convey("CreateMessage creates a span with a child span for the api request", t, func() {
+		tracer := &recordingTracer{}
+		client := NewClient("token", nil, WithTracer(tracer))
+
+		id, err := client.CreateMessage(&CreateMessageRequest{
+			Recipients: []string{"u1", "u2"},
+			MessageContentRequest: MessageContentRequest{
+				TemplateID:      "t1",
+				TemplateVersion: 2,
+				Title:           "title",
+			},
+		})
+		So(err, ShouldBeNil)
+		So(id, ShouldEqual, 7)
+
+		So(len(tracer.spans), ShouldEqual, 2)
+		So(tracer.spans[0].name, ShouldEqual, "sm.CreateMessage")
+		So(tracer.spans[0].attrs[AttrTemplateID], ShouldEqual, "t1")
+		So(tracer.spans[0].attrs[AttrRecipientCount], ShouldEqual, 2)
+		So(tracer.spans[0].attrs[AttrMessageID], ShouldEqual, 7)
+
+		So(tracer.spans[1].name, ShouldEqual, "POST /v1/messages")
+		So(tracer.spans[1].parent, ShouldEqual, "sm.CreateMessage")
+		So(tracer.spans[1].attrs[AttrHTTPStatus], ShouldEqual, http.StatusOK)
+		So(header, ShouldEqual, "POST /v1/messages")
+	})
+}
@@ -0,0 +1,184 @@
+package go_sdk
+
+import (
+	"encoding/json"
+	"errors"
+)
+
+var ErrDataNotObject = errors.New("message data must encode to a JSON object")
+
+// TypedMessage 为数据类型为 T 的消息内容，T 通常是 smgen 根据模板生成的数据结构：
+//      m := go_sdk.TypedMessage[TodoListData]{
+//          TemplateID:      TodoListTemplateID,
+//          TemplateVersion: TodoListTemplateVersion,
+//          Title:           "待办列表",
+//          Data:            data,
+//      }
+//      cmr, err := m.CreateRequest("openID")
+type TypedMessage[T any] struct {
+	TemplateID      string
+	TemplateVersion int32
+	Title           string
+	Data            T
+}
+
+// Content 将 Data 转换为 MessageContentRequest 使用的 map 形式
+func (m *TypedMessage[T]) Content() (MessageContentRequest, error) {
+	data, err := dataMap(m.Data)
+	if err != nil {
+		return MessageContentRequest{}, err
+	}
+
+	return MessageContentRequest{
+		TemplateID:      m.TemplateID,
+		TemplateVersion: m.TemplateVersion,
+		Title:           m.Title,
+		Data:            data,
+	}, nil
+}
+
+// CreateRequest 返回推送此消息给 recipients 的 CreateMessageRequest，recipients 为空时返回 ErrNoRecipients，
+// 发给频道全体成员需使用 CreateRequestToAll
+func (m *TypedMessage[T]) CreateRequest(recipients ...string) (*CreateMessageRequest, error) {
+	if len(recipients) == 0 {
+		return nil, ErrNoRecipients
+	}
+
+	mcr, err := m.Content()
+	if err != nil {
+		return nil, err
+	}
+
+	return &CreateMessageRequest{Recipients: recipients, MessageContentRequest: mcr}, nil
+}
+
+// CreateRequestToAll 返回推送此消息给频道全体成员的 CreateMessageRequest
+func (m *TypedMessage[T]) CreateRequestToAll() (*CreateMessageRequest, error) {
+	mcr, err := m.Content()
+	if err != nil {
+		return nil, err
+	}
+
+	return &CreateMessageRequest{ToAll: true, MessageContentRequest: mcr}, nil
+}
+
+// UpdateRequest 返回用此消息内容更新消息 messageID 的 UpdateMessageRequest
+func (m *TypedMessage[T]) UpdateRequest(messageID int64) (*UpdateMessageRequest, error) {
+	mcr, err := m.Content()
+	if err != nil {
+		return nil, err
+	}
+
+	return &UpdateMessageRequest{ID: messageID, MessageContentRequest: mcr}, nil
+}
+
+// NewMessage 返回在响应中发送此消息的 NewMessage
+func (m *TypedMessage[T]) NewMessage() *NewMessage {
+	return &NewMessage{
+		TemplateID:      m.TemplateID,
+		TemplateVersion: int(m.TemplateVersion),
+		Title:           m.Title,
+		Data:            m.Data,
+	}
+}
+
+// MessageData 将通过 GetMessage、ListMessages 查询到的消息数据解码为 T
+func MessageData[T any](m *Message) (data T, err error) {
+	b, err := json.Marshal(m.Data)
+	if err != nil {
+		return
+	}
+
+	err = json.Unmarshal(b, &data)
+	return
+}
+
+// dataMap 将任意数据转换为 JSON 对象形式的 map，nil 返回 nil
+func dataMap(v interface{}) (map[string]interface{}, error) {
+	if m, ok := v.(map[string]interface{}); ok {
+		return m, nil
+	}
+
+	n, err := normalize(v)
+	if err != nil {
+		return nil, err
+	}
+	if n == nil {
+		return nil, nil
+	}
+
+	m, ok := n.(map[string]interface{})
+	if !ok {
+		return nil, ErrDataNotObject
+	}
+	return m, nil
+}
+
+// UpdateThisMessageT 与 Response.UpdateThisMessage 相同，data 的类型在编译期确定
+func UpdateThisMessageT[T any](r *Response, q *QueryParameter, title string, data T) *Response {
+	return r.UpdateThisMessage(q, title, data)
+}
+
+// NewMessageT 设置响应中的新消息，data 的类型在编译期确定
+func NewMessageT[T any](r *Response, m *TypedMessage[T]) *Response {
+	r.New = m.NewMessage()
+	return r
+}
+
+// Field 为数据结构 T 中值类型为 V 的字段，由 smgen 根据模板生成，用于构造类型安全的 UpdatePart 操作：
+//      ops.AddOpSet(go_sdk.NewSetOf(TodoListFieldListDone(3).Value(true)))
+// 值的类型与字段不符，或者字段不属于同一个数据结构时无法通过编译
+type Field[T, V any] struct {
+	keyPath string
+}
+
+// NewField 返回 keyPath 对应的字段
+func NewField[T, V any](keyPath string) Field[T, V] {
+	return Field[T, V]{keyPath: keyPath}
+}
+
+// KeyPath 返回字段的 keypath
+func (f Field[T, V]) KeyPath() string {
+	return f.keyPath
+}
+
+// Value 返回将字段设置为 v 的赋值，用于 NewSetOf
+func (f Field[T, V]) Value(v V) FieldValue[T] {
+	return FieldValue[T]{keyPath: f.keyPath, value: v}
+}
+
+// FieldValue 为数据结构 T 中一个字段的赋值
+type FieldValue[T any] struct {
+	keyPath string
+	value   interface{}
+}
+
+// NewSetOf 返回由 values 组成的 Set 操作
+func NewSetOf[T any](values ...FieldValue[T]) *Set {
+	set := NewSet()
+	for _, v := range values {
+		set.Add(v.keyPath, v.value)
+	}
+	return set
+}
+
+// NewUnsetOf 返回删除 fields 的 Unset 操作
+func NewUnsetOf[T, V any](fields ...Field[T, V]) *Unset {
+	unset := NewUnset()
+	for _, f := range fields {
+		unset.Add(f.keyPath)
+	}
+	return unset
+}
+
+// NewInsertOf 返回在列表字段 f 的 index 位置插入 items 的 Insert 操作
+func NewInsertOf[T, V any](f Field[T, []V], items []V, index int) *Insert {
+	insert := NewInsert(f.keyPath, items)
+	insert.Index = index
+	return insert
+}
+
+// NewRemoveOf 返回删除列表字段 f 中 indexes 位置元素的 Remove 操作
+func NewRemoveOf[T, V any](f Field[T, []V], indexes ...int) *Remove {
+	return NewRemove(f.keyPath, indexes)
+}
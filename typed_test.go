@@ -0,0 +1,71 @@
+package go_sdk
+
+import (
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+type typedItem struct {
+	Title string `json:"title"`
+	Done  bool   `json:"done"`
+}
+
+type typedData struct {
+	Title string      `json:"title"`
+	List  []typedItem `json:"list"`
+}
+
+func TestTypedMessage(t *testing.T) {
+	Convey("Convert typed data to message requests", t, func() {
+		m := &TypedMessage[typedData]{
+			TemplateID:      "t1",
+			TemplateVersion: 2,
+			Title:           "todo",
+			Data:            typedData{Title: "today", List: []typedItem{{Title: "a"}}},
+		}
+
+		cmr, err := m.CreateRequest("u1")
+		So(err, ShouldBeNil)
+		So(cmr.ToAll, ShouldBeFalse)
+		So(cmr.TemplateVersion, ShouldEqual, 2)
+		So(cmr.Data["title"], ShouldEqual, "today")
+		So(cmr.Data["list"], ShouldResemble, []interface{}{map[string]interface{}{"title": "a", "done": false}})
+
+		_, err = m.CreateRequest()
+		So(err, ShouldEqual, ErrNoRecipients)
+		_, err = m.CreateRequest([]string{}...)
+		So(err, ShouldEqual, ErrNoRecipients)
+
+		all, err := m.CreateRequestToAll()
+		So(err, ShouldBeNil)
+		So(all.ToAll, ShouldBeTrue)
+		So(all.Recipients, ShouldBeEmpty)
+		So(all.Data, ShouldResemble, cmr.Data)
+
+		data, err := MessageData[typedData](&Message{Data: cmr.Data})
+		So(err, ShouldBeNil)
+		So(data, ShouldResemble, m.Data)
+
+		_, err = (&TypedMessage[[]int]{Data: []int{1}}).Content()
+		So(err, ShouldEqual, ErrDataNotObject)
+	})
+
+	Convey("Build operations from typed fields", t, func() {
+		title := NewField[typedData, string]("title")
+		list := NewField[typedData, []typedItem]("list")
+		done := NewField[typedData, bool]("list.0.done")
+
+		ops := NewUpdatePart()
+		ops.AddOpSet(NewSetOf(title.Value("tomorrow"), done.Value(true)))
+		ops.AddOpInsert(NewInsertOf(list, []typedItem{{Title: "b"}}, -1))
+
+		data := map[string]interface{}{"title": "today", "list": []interface{}{map[string]interface{}{"title": "a", "done": false}}}
+		So(ops.Apply(data), ShouldBeNil)
+		So(data["title"], ShouldEqual, "tomorrow")
+		So(data["list"], ShouldResemble, []interface{}{
+			map[string]interface{}{"title": "a", "done": true},
+			map[string]interface{}{"title": "b", "done": false},
+		})
+	})
+}